@@ -1,17 +1,57 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jaxron/roapi.go/pkg/api"
+	"github.com/robalyx/rotten/internal/cli"
+	"github.com/robalyx/rotten/internal/exports"
+	"github.com/robalyx/rotten/internal/friends"
 	"github.com/robalyx/rotten/internal/tui"
 )
 
 func main() {
-	p := tea.NewProgram(tui.NewModel())
+	// Run non-interactive commands when a command is given, and show their usage on help
+	if len(os.Args) > 1 && (!strings.HasPrefix(os.Args[1], "-") || isHelp(os.Args[1])) {
+		// Stop long-running checks on interrupt, keeping the results checked so far
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+		app := cli.New(os.Stdin, os.Stdout, os.Stderr, friends.NewFetcher(api.New(nil)), exports.New("robalyx", "rotten"))
+		code := app.Run(ctx, os.Args[1:])
+		stop()
+		os.Exit(code)
+	}
+
+	// Parse flags that preselect menu values
+	opts, err := tui.ParseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		// List the commands too, since help was asked for after other flags
+		os.Exit(cli.New(nil, os.Stdout, os.Stderr, nil, nil).Run(context.Background(), []string{"help"}))
+	}
+	if err != nil {
+		os.Exit(cli.ExitError)
+	}
+
+	p := tea.NewProgram(tui.NewModelWithOptions(opts))
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// isHelp reports whether the argument asks for the usage.
+func isHelp(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help":
+		return true
+	default:
+		return false
+	}
+}
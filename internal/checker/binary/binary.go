@@ -1,6 +1,9 @@
 package binary
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
@@ -8,40 +11,214 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/hasher"
 )
 
 var ErrInvalidFormat = errors.New("invalid file format")
 
+// exportFile is an open binary file for a single check type.
+type exportFile struct {
+	file  *os.File
+	data  []byte      // The file contents if memory-mapped
+	r     io.ReaderAt // Reads from data if memory-mapped, otherwise from file
+	size  int64
+	count uint32
+	v2    *v2Header // Set for version 2 files
+	err   error     // Set if the file could not be opened
+}
+
 // Checker implements the common.Checker interface for binary storage.
+// The files are opened once and memory-mapped where supported. Otherwise they are read through
+// independent section readers. Either way, lookups can run concurrently.
 type Checker struct {
-	dir string
+	dir     string
+	hashLen int // Length of the hashes in version 1 files, which have no header to read it from
+	mu      sync.RWMutex
+	users   *exportFile
+	groups  *exportFile
 }
 
-// New creates a new binary checker.
+// New creates a new binary checker and opens the user and group files.
+// A file that cannot be opened returns its error on every lookup.
 func New(dir string) *Checker {
-	return &Checker{dir: dir}
+	return newChecker(dir, true)
+}
+
+// newChecker creates a new binary checker, memory-mapping the files if useMmap is set.
+func newChecker(dir string, useMmap bool) *Checker {
+	return &Checker{
+		dir:     dir,
+		hashLen: hasher.Size,
+		users:   openFile(filepath.Join(dir, "users.bin"), useMmap),
+		groups:  openFile(filepath.Join(dir, "groups.bin"), useMmap),
+	}
+}
+
+// openFile opens a binary file, detects its version and validates its format.
+func openFile(path string, useMmap bool) *exportFile {
+	f := &exportFile{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		f.err = fmt.Errorf("failed to open file: %w", err)
+		return f
+	}
+	f.file, f.r = file, file
+
+	// Files that cannot be mapped are read with regular reads instead
+	if useMmap {
+		if err := f.mmap(); err != nil {
+			f.data, f.r = nil, file
+		}
+	}
+
+	// Validate file format
+	if err := f.open(); err != nil {
+		f.close()
+		f.err = err
+		return f
+	}
+
+	return f
+}
+
+// mmap maps the file into memory.
+func (f *exportFile) mmap() error {
+	stat, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file stats: %w", err)
+	}
+	if stat.Size() == 0 {
+		return fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	}
+
+	data, err := mmapFile(f.file, stat.Size())
+	if err != nil {
+		return err
+	}
+	f.data, f.r = data, bytes.NewReader(data)
+	return nil
+}
+
+// close unmaps and closes the file.
+func (f *exportFile) close() error {
+	var errs []error
+	if f.data != nil {
+		if err := munmap(f.data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unmap file: %w", err))
+		}
+		f.data = nil
+	}
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file: %w", err))
+		}
+		f.file, f.r = nil, nil
+	}
+	return errors.Join(errs...)
+}
+
+// open validates the format of the file and reads its layout.
+func (f *exportFile) open() error {
+	stat, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file stats: %w", err)
+	}
+	f.size = stat.Size()
+
+	// Version 1 files have no header
+	header := make([]byte, v2HeaderSize)
+	n, err := f.r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	if !isV2(header[:n]) {
+		f.count, err = validateFileFormat(f.r, f.size)
+		return err
+	}
+
+	if f.v2, err = parseV2Header(header[:n], f.size); err != nil {
+		return err
+	}
+	if err := verifyChecksum(f.r, f.size); err != nil {
+		return err
+	}
+	f.count = f.v2.count
+	return nil
+}
+
+// Version returns the format version of the file for the check type.
+func (c *Checker) Version(checkType common.CheckType) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return 0, err
+	}
+	if f.v2 != nil {
+		return Version2, nil
+	}
+	return Version1, nil
+}
+
+// exportFile returns the open file for the check type.
+func (c *Checker) exportFile(checkType common.CheckType) (*exportFile, error) {
+	f := c.users
+	if checkType == common.CheckTypeGroup {
+		f = c.groups
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.file == nil {
+		return nil, common.ErrCheckerClosed
+	}
+	return f, nil
+}
+
+// records returns a reader positioned at the first record of a version 1 file.
+func (f *exportFile) records() *io.SectionReader {
+	return io.NewSectionReader(f.file, 4, f.size-4)
 }
 
 // Check verifies if the given ID exists in the binary file.
-func (c *Checker) Check(checkType common.CheckType, id string) (*common.CheckResult, error) {
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Convert input ID to bytes
 	searchHash, err := hex.DecodeString(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid hash format: %w", err)
 	}
 
-	// Open and validate file
-	file, count, err := c.openAndValidateFile(checkType)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	if f.v2 != nil {
+		return f.v2.lookup(f.r, searchHash)
+	}
+	if f.data != nil {
+		return f.scanMapped(ctx, searchHash)
+	}
 
 	// Read and compare each record
+	file := f.records()
 	hashBuf := make([]byte, len(searchHash))
-	for range count {
+	for range f.count {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// Read and compare hash
 		if found, result, err := c.readAndCompareHash(file, hashBuf, searchHash); err != nil {
 			return nil, err
@@ -58,54 +235,130 @@ func (c *Checker) Check(checkType common.CheckType, id string) (*common.CheckRes
 	return &common.CheckResult{}, nil
 }
 
-// openAndValidateFile opens the binary file and validates its format.
-func (c *Checker) openAndValidateFile(checkType common.CheckType) (*os.File, uint32, error) {
-	// Determine filename based on check type
-	filename := "users.bin"
-	if checkType == common.CheckTypeGroup {
-		filename = "groups.bin"
+// CheckMany verifies which of the given hashes exist in the binary file,
+// using a single pass over version 1 files and an index search per hash for version 2 files.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// Open file
-	file, err := os.Open(filepath.Join(c.dir, filename))
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	// Map each decoded hash to its positions in the results
+	results := make([]*common.CheckResult, len(hashes))
+	positions := make(map[string][]int, len(hashes))
+	hashLen := 0
+	for i, hash := range hashes {
+		searchHash, err := hex.DecodeString(hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hash format: %w", err)
+		}
+		if i > 0 && len(searchHash) != hashLen {
+			return nil, fmt.Errorf("invalid hash format: %s has %d bytes, expected %d", hash, len(searchHash), hashLen)
+		}
+		hashLen = len(searchHash)
+
+		results[i] = &common.CheckResult{}
+		positions[string(searchHash)] = append(positions[string(searchHash)], i)
+	}
+	if len(hashes) == 0 {
+		return results, nil
 	}
 
-	// Validate file format
-	count, err := c.validateFileFormat(file)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
 	if err != nil {
-		file.Close()
-		return nil, 0, err
+		return nil, err
 	}
 
-	return file, count, nil
+	// Version 2 files are searched once per distinct hash
+	if f.v2 != nil {
+		for hash, indices := range positions {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			result, err := f.v2.lookup(f.r, []byte(hash))
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range indices {
+				results[i] = result
+			}
+		}
+		return results, nil
+	}
+
+	scan := c.scanMany
+	if f.data != nil {
+		scan = scanManyMapped
+	}
+	if err := scan(ctx, f, hashLen, positions, results); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
-// validateFileFormat checks the file size and reads the record count.
-func (c *Checker) validateFileFormat(file *os.File) (uint32, error) {
-	// Get file size
-	stat, err := file.Stat()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get file stats: %w", err)
+// scanMany reads each record of a version 1 file until every hash in positions has been found,
+// storing the found records in results.
+func (c *Checker) scanMany(
+	ctx context.Context, f *exportFile, hashLen int, positions map[string][]int, results []*common.CheckResult,
+) error {
+	file := f.records()
+	hashBuf := make([]byte, hashLen)
+	for range f.count {
+		if len(positions) == 0 {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(file, hashBuf); err != nil {
+			return fmt.Errorf("failed to read hash: %w", err)
+		}
+
+		indices, ok := positions[string(hashBuf)]
+		if !ok {
+			if err := c.skipRecordData(file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := c.readRecordData(file)
+		if err != nil {
+			return err
+		}
+		for _, i := range indices {
+			results[i] = result
+		}
+		delete(positions, string(hashBuf))
 	}
 
+	return nil
+}
+
+// validateFileFormat checks the size of a version 1 file and reads the record count.
+func validateFileFormat(r io.ReaderAt, size int64) (uint32, error) {
 	// Validate minimum file size
 	minFileSize := int64(4) // minimum size for count
-	if stat.Size() < minFileSize {
+	if size < minFileSize {
 		return 0, fmt.Errorf("%w: file too small", ErrInvalidFormat)
 	}
 
 	// Read count of hashes
-	var count uint32
-	if err := binary.Read(file, binary.LittleEndian, &count); err != nil {
+	var countBuf [4]byte
+	if _, err := r.ReadAt(countBuf[:], 0); err != nil {
 		return 0, fmt.Errorf("%w: failed to read count", ErrInvalidFormat)
 	}
+	count := binary.LittleEndian.Uint32(countBuf[:])
 
 	// Validate count against file size
 	minRecordSize := len("0123456789abcdef") + 4 // hash length + 4 bytes for lengths
 	expectedMinSize := 4 + (int64(count) * int64(minRecordSize))
-	if stat.Size() < expectedMinSize {
+	if size < expectedMinSize {
 		return 0, fmt.Errorf("%w: file size too small for count", ErrInvalidFormat)
 	}
 
@@ -113,7 +366,7 @@ func (c *Checker) validateFileFormat(file *os.File) (uint32, error) {
 }
 
 // readAndCompareHash reads a hash from the file and compares it with the search hash.
-func (c *Checker) readAndCompareHash(file *os.File, hashBuf, searchHash []byte) (bool, *common.CheckResult, error) {
+func (c *Checker) readAndCompareHash(file io.Reader, hashBuf, searchHash []byte) (bool, *common.CheckResult, error) {
 	// Read hash
 	if _, err := io.ReadFull(file, hashBuf); err != nil {
 		return false, nil, fmt.Errorf("failed to read hash: %w", err)
@@ -132,7 +385,7 @@ func (c *Checker) readAndCompareHash(file *os.File, hashBuf, searchHash []byte)
 }
 
 // readRecordData reads the status, reason, and confidence for a matching record.
-func (c *Checker) readRecordData(file *os.File) (*common.CheckResult, error) {
+func (c *Checker) readRecordData(file io.Reader) (*common.CheckResult, error) {
 	var result common.CheckResult
 	result.Found = true
 
@@ -159,7 +412,7 @@ func (c *Checker) readRecordData(file *os.File) (*common.CheckResult, error) {
 }
 
 // readLengthAndData reads a length-prefixed string from the file.
-func (c *Checker) readLengthAndData(file *os.File, fieldName string) ([]byte, error) {
+func (c *Checker) readLengthAndData(file io.Reader, fieldName string) ([]byte, error) {
 	var length uint16
 	if err := binary.Read(file, binary.LittleEndian, &length); err != nil {
 		return nil, fmt.Errorf("failed to read %s length: %w", fieldName, err)
@@ -174,7 +427,7 @@ func (c *Checker) readLengthAndData(file *os.File, fieldName string) ([]byte, er
 }
 
 // skipRecordData skips over the status, reason, and confidence fields.
-func (c *Checker) skipRecordData(file *os.File) error {
+func (c *Checker) skipRecordData(file io.ReadSeeker) error {
 	var skipLen uint16
 	// Skip status
 	if err := binary.Read(file, binary.LittleEndian, &skipLen); err != nil {
@@ -196,43 +449,81 @@ func (c *Checker) skipRecordData(file *os.File) error {
 }
 
 // GetHashCount returns the number of hashes in the binary file.
-func (c *Checker) GetHashCount(checkType common.CheckType) (uint64, error) {
-	// Determine filename based on check type
-	filename := "users.bin"
-	if checkType == common.CheckTypeGroup {
-		filename = "groups.bin"
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
 
-	// Open file
-	file, err := os.Open(filepath.Join(c.dir, filename))
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open file: %w", err)
+		return 0, err
 	}
-	defer file.Close()
 
-	// Get file size
-	stat, err := file.Stat()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get file stats: %w", err)
+	return uint64(f.count), nil
+}
+
+// Each calls fn with every record in the binary file, stopping at the first error.
+// Version 1 records are expected to hold hashes of hasher.Size bytes.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Check minimum file size
-	if stat.Size() < 4 {
-		return 0, fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return err
 	}
 
-	// Read count of hashes
-	var count uint32
-	if err := binary.Read(file, binary.LittleEndian, &count); err != nil {
-		return 0, fmt.Errorf("%w: failed to read count", ErrInvalidFormat)
+	switch {
+	case f.v2 != nil:
+		return f.eachV2(ctx, fn)
+	case f.data != nil:
+		var fnErr error
+		err := f.eachMapped(ctx, c.hashLen, func(record *v1Record) bool {
+			fnErr = fn(hex.EncodeToString(record.hash), record.result())
+			return fnErr == nil
+		})
+		if fnErr != nil {
+			return fnErr
+		}
+		return err
 	}
 
-	// Validate count against file size
-	minRecordSize := len("0123456789abcdef") + 4 // hash length + 4 bytes for lengths
-	expectedMinSize := 4 + (int64(count) * int64(minRecordSize))
-	if stat.Size() < expectedMinSize {
-		return 0, fmt.Errorf("%w: file size too small for count", ErrInvalidFormat)
+	// Read each record, buffering the small reads of the fields
+	file := bufio.NewReader(f.records())
+	hashBuf := make([]byte, c.hashLen)
+	for i := range f.count {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if _, err := io.ReadFull(file, hashBuf); err != nil {
+			return fmt.Errorf("failed to read hash: %w", err)
+		}
+		result, err := c.readRecordData(file)
+		if err != nil {
+			return err
+		}
+		if err := fn(hex.EncodeToString(hashBuf), result); err != nil {
+			return err
+		}
 	}
 
-	return uint64(count), nil
+	return nil
+}
+
+// Close unmaps and closes the files. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return errors.Join(c.users.close(), c.groups.close())
 }
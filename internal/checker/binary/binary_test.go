@@ -1,6 +1,7 @@
 package binary
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"os"
@@ -72,7 +73,7 @@ func TestChecker_Check(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			result, err := checker.Check(tt.checkType, tt.hash)
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -123,7 +124,7 @@ func TestChecker_GetHashCount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			count, err := checker.GetHashCount(tt.checkType)
+			count, err := checker.GetHashCount(context.Background(), tt.checkType)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -141,12 +142,12 @@ func TestChecker_NonexistentFile(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with nonexistent file
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with nonexistent file
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -161,12 +162,12 @@ func TestChecker_InvalidFileFormat(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with invalid file format
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with invalid file format
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -212,7 +213,7 @@ func TestChecker_WithData(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test finding the record
-	result, err := checker.Check(common.CheckTypeUser, testHash)
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, testHash)
 	assert.NoError(t, err)
 	assert.True(t, result.Found)
 	assert.Equal(t, testStatus, result.Status)
@@ -220,7 +221,7 @@ func TestChecker_WithData(t *testing.T) {
 	assert.Equal(t, testConfidence, result.Confidence)
 
 	// Test count
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(1), count)
 }
@@ -229,8 +230,73 @@ func TestChecker_InvalidHashFormat(t *testing.T) {
 	tempDir := t.TempDir()
 	checker := New(tempDir)
 
-	result, err := checker.Check(common.CheckTypeUser, "invalid")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "invalid")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "invalid hash format")
 }
+
+func TestChecker_CheckMany(t *testing.T) {
+	tempDir := t.TempDir()
+	records := []struct {
+		hash   string
+		status string
+	}{
+		{hash: "0123456789abcdef", status: "banned"},
+		{hash: "fedcba9876543210", status: "flagged"},
+	}
+
+	// Create test file with two records
+	f, err := os.Create(filepath.Join(tempDir, "users.bin"))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, binary.Write(f, binary.LittleEndian, uint32(len(records))))
+	for _, record := range records {
+		hashBytes, err := hex.DecodeString(record.hash)
+		require.NoError(t, err)
+		_, err = f.Write(hashBytes)
+		require.NoError(t, err)
+		require.NoError(t, binary.Write(f, binary.LittleEndian, uint16(len(record.status))))
+		_, err = f.Write([]byte(record.status))
+		require.NoError(t, err)
+		require.NoError(t, binary.Write(f, binary.LittleEndian, uint16(0)))
+		require.NoError(t, binary.Write(f, binary.LittleEndian, 0.5))
+	}
+
+	checker := New(tempDir)
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"fedcba9876543210", "1111111111111111", "0123456789abcdef"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Found)
+	assert.Equal(t, "flagged", results[0].Status)
+	assert.False(t, results[1].Found)
+	assert.True(t, results[2].Found)
+	assert.Equal(t, "banned", results[2].Status)
+	assert.InDelta(t, 0.5, results[2].Confidence, 0.001)
+
+	// Hashes must all have the same length
+	_, err = checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"0123456789abcdef", "0123"})
+	assert.ErrorContains(t, err, "invalid hash format")
+
+	_, err = checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"invalid"})
+	assert.ErrorContains(t, err, "invalid hash format")
+}
+
+func TestChecker_Close(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	checker := New(tempDir)
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
+	require.NoError(t, err)
+
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close(), "Close should be idempotent")
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.CheckMany(context.Background(), common.CheckTypeGroup, []string{"0123456789abcdef"})
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeGroup)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
@@ -0,0 +1,75 @@
+package binary
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// ctxCheckInterval is the number of records scanned between checks of the context.
+const ctxCheckInterval = 1024
+
+// eachMapped calls fn with each record of a memory-mapped version 1 file until fn returns false.
+// Records are parsed in place, so only the records fn keeps are copied.
+func (f *exportFile) eachMapped(ctx context.Context, hashLen int, fn func(record *v1Record) bool) error {
+	// Reuse a single record so that scanning does not allocate
+	var record v1Record
+	offset := 4
+	for i := range f.count {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		var next int
+		var err error
+		record, next, err = parseV1Record(f.data, offset, hashLen)
+		if err != nil {
+			return err
+		}
+		offset = next
+
+		if !fn(&record) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// scanMapped looks up a hash in a memory-mapped version 1 file.
+func (f *exportFile) scanMapped(ctx context.Context, searchHash []byte) (*common.CheckResult, error) {
+	result := &common.CheckResult{}
+	err := f.eachMapped(ctx, len(searchHash), func(record *v1Record) bool {
+		if !bytes.Equal(record.hash, searchHash) {
+			return true
+		}
+		result = record.result()
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scanManyMapped looks up the hashes in positions in a memory-mapped version 1 file,
+// storing the found records in results.
+func scanManyMapped(
+	ctx context.Context, f *exportFile, hashLen int, positions map[string][]int, results []*common.CheckResult,
+) error {
+	return f.eachMapped(ctx, hashLen, func(record *v1Record) bool {
+		indices, ok := positions[string(record.hash)]
+		if !ok {
+			return true
+		}
+
+		result := record.result()
+		for _, i := range indices {
+			results[i] = result
+		}
+		delete(positions, string(record.hash))
+		return len(positions) > 0
+	})
+}
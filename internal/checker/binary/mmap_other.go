@@ -0,0 +1,20 @@
+//go:build !unix
+
+package binary
+
+import (
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("memory mapping is not supported on this platform")
+
+// mmapFile always fails, so files are read with regular reads instead.
+func mmapFile(*os.File, int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+// munmap does nothing, since nothing is ever mapped.
+func munmap([]byte) error {
+	return nil
+}
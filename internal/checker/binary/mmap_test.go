@@ -0,0 +1,179 @@
+package binary
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeV1File writes n version 1 records with 32-byte hashes, returning the hash of the last record.
+func writeV1File(tb testing.TB, path string, n int) string {
+	tb.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(tb, binary.Write(&buf, binary.LittleEndian, uint32(n))) //nolint:gosec
+	hash := make([]byte, 32)
+	for i := range n {
+		binary.BigEndian.PutUint64(hash, uint64(i)) //nolint:gosec
+		buf.Write(hash)
+		status := fmt.Sprintf("status%d", i%3)
+		require.NoError(tb, binary.Write(&buf, binary.LittleEndian, uint16(len(status))))
+		buf.WriteString(status)
+		require.NoError(tb, binary.Write(&buf, binary.LittleEndian, uint16(len("reason"))))
+		buf.WriteString("reason")
+		require.NoError(tb, binary.Write(&buf, binary.LittleEndian, 0.5))
+	}
+	require.NoError(tb, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	return hex.EncodeToString(hash)
+}
+
+func TestChecker_MmapMatchesReads(t *testing.T) {
+	tempDir := t.TempDir()
+	last := writeV1File(t, filepath.Join(tempDir, "users.bin"), 100)
+	writeV2File(t, filepath.Join(tempDir, "groups.bin"), testV2Records)
+
+	mapped := newChecker(tempDir, true)
+	defer mapped.Close()
+	read := newChecker(tempDir, false)
+	defer read.Close()
+
+	assert.Nil(t, read.users.data)
+	if mapped.users.data == nil {
+		t.Skip("memory mapping is not supported on this platform")
+	}
+
+	tests := []struct {
+		name      string
+		checkType common.CheckType
+		hashes    []string
+	}{
+		{name: "Version 1", checkType: common.CheckTypeUser, hashes: []string{last, hex.EncodeToString(make([]byte, 32))}},
+		{name: "Version 2", checkType: common.CheckTypeGroup, hashes: []string{"7f7f7f7f", "00000000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, hash := range tt.hashes {
+				want, wantErr := read.Check(context.Background(), tt.checkType, hash)
+				got, err := mapped.Check(context.Background(), tt.checkType, hash)
+				assert.Equal(t, wantErr, err)
+				assert.Equal(t, want, got)
+			}
+
+			want, err := read.CheckMany(context.Background(), tt.checkType, tt.hashes[:2])
+			require.NoError(t, err)
+			got, err := mapped.CheckMany(context.Background(), tt.checkType, tt.hashes[:2])
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	result, err := mapped.Check(context.Background(), common.CheckTypeUser, last)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "status0", result.Status)
+}
+
+func TestChecker_MmapTruncated(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "users.bin")
+	last := writeV1File(t, path, 10)
+
+	// Drop the confidence of the last record
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-8], 0o600))
+
+	checker := New(tempDir)
+	defer checker.Close()
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, last)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func BenchmarkChecker_Check(b *testing.B) {
+	tempDir := b.TempDir()
+	last := writeV1File(b, filepath.Join(tempDir, "users.bin"), 10000)
+
+	for _, bm := range []struct {
+		name    string
+		useMmap bool
+	}{
+		{name: "mmap", useMmap: true},
+		{name: "read", useMmap: false},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			checker := newChecker(tempDir, bm.useMmap)
+			defer checker.Close()
+
+			b.ResetTimer()
+			for range b.N {
+				if _, err := checker.Check(context.Background(), common.CheckTypeUser, last); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_Each(t *testing.T) {
+	tempDir := t.TempDir()
+	last := writeV1File(t, filepath.Join(tempDir, "users.bin"), 100)
+	writeV2File(t, filepath.Join(tempDir, "groups.bin"), testV2Records)
+
+	for _, useMmap := range []bool{true, false} {
+		t.Run(fmt.Sprintf("mmap=%t", useMmap), func(t *testing.T) {
+			checker := newChecker(tempDir, useMmap)
+			defer checker.Close()
+
+			var hashes []string
+			err := checker.Each(context.Background(), common.CheckTypeUser, func(hash string, result *common.CheckResult) error {
+				hashes = append(hashes, hash)
+				assert.Equal(t, "reason", result.Reason)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, hashes, 100)
+			assert.Equal(t, last, hashes[99])
+
+			// Version 2 records are enumerated in index order
+			var results []common.CheckResult
+			hashes = nil
+			err = checker.Each(context.Background(), common.CheckTypeGroup, func(hash string, result *common.CheckResult) error {
+				hashes = append(hashes, hash)
+				results = append(results, *result)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"00000001", "7f7f7f7f", "ffff0000"}, hashes)
+			assert.Equal(t, common.CheckResult{Found: true, Status: "flagged", Reason: "spam", Confidence: 0.5}, results[0])
+		})
+	}
+
+	// Truncated version 1 files fail the enumeration
+	path := filepath.Join(tempDir, "users.bin")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-4], 0o600))
+	for _, useMmap := range []bool{true, false} {
+		checker := newChecker(tempDir, useMmap)
+		calls := 0
+		err := checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error {
+			calls++
+			return nil
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 99, calls)
+		checker.Close()
+	}
+}
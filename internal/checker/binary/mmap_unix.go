@@ -0,0 +1,24 @@
+//go:build unix
+
+package binary
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile maps the file into memory read-only.
+func mmapFile(file *os.File, size int64) ([]byte, error) {
+	data, err := unix.Mmap(int(file.Fd()), 0, int(size), unix.PROT_READ, unix.MAP_SHARED) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to map file: %w", err)
+	}
+	return data, nil
+}
+
+// munmap unmaps memory returned by mmapFile.
+func munmap(data []byte) error {
+	return unix.Munmap(data)
+}
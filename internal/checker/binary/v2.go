@@ -0,0 +1,320 @@
+package binary
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Version 2 of the binary format adds a header, sorts the records by hash so lookups can binary-search them,
+// and stores the status and reason strings once in a string table. All integers are little-endian:
+//
+//	header   magic "RTNB" | version uint16 | hash length uint16 | record count uint32 | string table size uint32
+//	index    one entry per record, sorted by hash:
+//	         hash | status offset uint32 | reason offset uint32 | confidence float64
+//	strings  length-prefixed strings: length uint16 | bytes
+//	footer   CRC-32 (IEEE) of everything before the footer
+//
+// Version 1 files have no header and start with the record count, followed by the records in no
+// particular order.
+const (
+	Version1 = 1
+	Version2 = 2
+
+	v2Magic      = "RTNB"
+	v2HeaderSize = 16
+	v2FooterSize = 4
+)
+
+var (
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrUnsortedIndex    = errors.New("index is not sorted")
+)
+
+// Record is a single record to write to a binary file.
+type Record struct {
+	Hash       string // Hex-encoded
+	Status     string
+	Reason     string
+	Confidence float64
+}
+
+// v2Header describes the layout of a version 2 file.
+type v2Header struct {
+	hashLen   int
+	count     uint32
+	tableSize uint32
+}
+
+// entrySize returns the size of an index entry.
+func (h *v2Header) entrySize() int64 {
+	return int64(h.hashLen) + 16
+}
+
+// entryOffset returns the file offset of the i-th index entry.
+func (h *v2Header) entryOffset(i int) int64 {
+	return v2HeaderSize + int64(i)*h.entrySize()
+}
+
+// tableOffset returns the file offset of the string table.
+func (h *v2Header) tableOffset() int64 {
+	return h.entryOffset(int(h.count)) //nolint:gosec
+}
+
+// isV2 reports whether the start of a file holds the version 2 magic.
+func isV2(start []byte) bool {
+	return len(start) >= len(v2Magic) && string(start[:len(v2Magic)]) == v2Magic
+}
+
+// parseV2Header parses the header of a version 2 file and checks it against the file size.
+func parseV2Header(header []byte, size int64) (*v2Header, error) {
+	if len(header) < v2HeaderSize || size < v2HeaderSize+v2FooterSize {
+		return nil, fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	}
+	if !isV2(header) {
+		return nil, fmt.Errorf("%w: missing magic", ErrInvalidFormat)
+	}
+
+	if version := binary.LittleEndian.Uint16(header[4:]); version != Version2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+
+	h := &v2Header{
+		hashLen:   int(binary.LittleEndian.Uint16(header[6:])),
+		count:     binary.LittleEndian.Uint32(header[8:]),
+		tableSize: binary.LittleEndian.Uint32(header[12:]),
+	}
+	if h.hashLen == 0 {
+		return nil, fmt.Errorf("%w: hash length is zero", ErrInvalidFormat)
+	}
+
+	// The sections must exactly fill the file
+	if expected := h.tableOffset() + int64(h.tableSize) + v2FooterSize; size != expected {
+		return nil, fmt.Errorf("%w: file size %d does not match header, expected %d", ErrInvalidFormat, size, expected)
+	}
+
+	return h, nil
+}
+
+// verifyChecksum compares the CRC-32 of a version 2 file with its footer.
+func verifyChecksum(r io.ReaderAt, size int64) error {
+	crc := crc32.NewIEEE()
+	if _, err := io.Copy(crc, io.NewSectionReader(r, 0, size-v2FooterSize)); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	footer := make([]byte, v2FooterSize)
+	if _, err := r.ReadAt(footer, size-v2FooterSize); err != nil {
+		return fmt.Errorf("failed to read footer: %w", err)
+	}
+
+	if expected := binary.LittleEndian.Uint32(footer); crc.Sum32() != expected {
+		return fmt.Errorf("%w: got %08x, expected %08x", ErrChecksumMismatch, crc.Sum32(), expected)
+	}
+	return nil
+}
+
+// readTableString reads a length-prefixed string from the string table at the given table offset.
+func (h *v2Header) readTableString(r io.ReaderAt, offset uint32, fieldName string) (string, error) {
+	if int64(offset)+2 > int64(h.tableSize) {
+		return "", fmt.Errorf("%w: %s offset %d is outside the string table", ErrInvalidFormat, fieldName, offset)
+	}
+
+	var lengthBuf [2]byte
+	if _, err := r.ReadAt(lengthBuf[:], h.tableOffset()+int64(offset)); err != nil {
+		return "", fmt.Errorf("failed to read %s length: %w", fieldName, err)
+	}
+
+	length := int64(binary.LittleEndian.Uint16(lengthBuf[:]))
+	if int64(offset)+2+length > int64(h.tableSize) {
+		return "", fmt.Errorf("%w: %s runs past the string table", ErrInvalidFormat, fieldName)
+	}
+
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, h.tableOffset()+int64(offset)+2); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", fieldName, err)
+	}
+	return string(buf), nil
+}
+
+// lookup binary-searches the index for the hash.
+func (h *v2Header) lookup(r io.ReaderAt, hash []byte) (*common.CheckResult, error) {
+	if len(hash) != h.hashLen {
+		return nil, fmt.Errorf("invalid hash format: %d bytes, expected %d", len(hash), h.hashLen)
+	}
+
+	// Find the first entry whose hash is not less than the search hash
+	entry := make([]byte, h.entrySize())
+	var readErr error
+	count := int(h.count) //nolint:gosec
+	i := sort.Search(count, func(i int) bool {
+		if readErr != nil {
+			return true
+		}
+		if _, err := r.ReadAt(entry[:h.hashLen], h.entryOffset(i)); err != nil {
+			readErr = err
+			return true
+		}
+		return bytes.Compare(entry[:h.hashLen], hash) >= 0
+	})
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read index: %w", readErr)
+	}
+	if i == count {
+		return &common.CheckResult{}, nil
+	}
+
+	if _, err := r.ReadAt(entry, h.entryOffset(i)); err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	if !bytes.Equal(entry[:h.hashLen], hash) {
+		return &common.CheckResult{}, nil
+	}
+
+	return h.readEntry(r, entry)
+}
+
+// readEntry reads the record of an index entry, resolving its strings from the string table.
+func (h *v2Header) readEntry(r io.ReaderAt, entry []byte) (*common.CheckResult, error) {
+	fields := entry[h.hashLen:]
+
+	status, err := h.readTableString(r, binary.LittleEndian.Uint32(fields), "status")
+	if err != nil {
+		return nil, err
+	}
+	reason, err := h.readTableString(r, binary.LittleEndian.Uint32(fields[4:]), "reason")
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.CheckResult{
+		Found:      true,
+		Status:     status,
+		Reason:     reason,
+		Confidence: math.Float64frombits(binary.LittleEndian.Uint64(fields[8:])),
+	}, nil
+}
+
+// eachV2 calls fn with every record in the index of a version 2 file, stopping at the first error.
+func (f *exportFile) eachV2(ctx context.Context, fn common.RecordFunc) error {
+	h := f.v2
+	entry := make([]byte, h.entrySize())
+	for i := range int(h.count) { //nolint:gosec
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if _, err := f.r.ReadAt(entry, h.entryOffset(i)); err != nil {
+			return fmt.Errorf("failed to read index: %w", err)
+		}
+		result, err := h.readEntry(f.r, entry)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if err := fn(hex.EncodeToString(entry[:h.hashLen]), result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteV2 writes the records to w in the version 2 format.
+// All hashes must be unique and hex-encoded hashes of hashLen bytes.
+func WriteV2(w io.Writer, hashLen int, records []Record) error {
+	if hashLen <= 0 || hashLen > math.MaxUint16 || uint64(len(records)) > math.MaxUint32 {
+		return fmt.Errorf("%w: invalid hash length or too many records", ErrInvalidFormat)
+	}
+
+	// Decode and sort the hashes
+	type entry struct {
+		hash   []byte
+		record *Record
+	}
+	entries := make([]entry, len(records))
+	for i := range records {
+		hash, err := hex.DecodeString(records[i].Hash)
+		if err != nil {
+			return fmt.Errorf("invalid hash format: %w", err)
+		}
+		if len(hash) != hashLen {
+			return fmt.Errorf("invalid hash format: %s has %d bytes, expected %d", records[i].Hash, len(hash), hashLen)
+		}
+		entries[i] = entry{hash: hash, record: &records[i]}
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return bytes.Compare(a.hash, b.hash)
+	})
+
+	// Build the string table, storing each distinct string once
+	var table bytes.Buffer
+	offsets := make(map[string]uint32)
+	addString := func(s string) (uint32, error) {
+		if offset, ok := offsets[s]; ok {
+			return offset, nil
+		}
+		if len(s) > math.MaxUint16 || uint64(table.Len())+2+uint64(len(s)) > math.MaxUint32 {
+			return 0, fmt.Errorf("%w: string table too large", ErrInvalidFormat)
+		}
+
+		offset := uint32(table.Len()) //nolint:gosec
+		_ = binary.Write(&table, binary.LittleEndian, uint16(len(s)))
+		table.WriteString(s)
+		offsets[s] = offset
+		return offset, nil
+	}
+
+	// Build the index
+	var index bytes.Buffer
+	for i, e := range entries {
+		if i > 0 && bytes.Equal(e.hash, entries[i-1].hash) {
+			return fmt.Errorf("%w: duplicate hash %s", ErrInvalidFormat, e.record.Hash)
+		}
+
+		statusOffset, err := addString(e.record.Status)
+		if err != nil {
+			return err
+		}
+		reasonOffset, err := addString(e.record.Reason)
+		if err != nil {
+			return err
+		}
+
+		index.Write(e.hash)
+		_ = binary.Write(&index, binary.LittleEndian, statusOffset)
+		_ = binary.Write(&index, binary.LittleEndian, reasonOffset)
+		_ = binary.Write(&index, binary.LittleEndian, e.record.Confidence)
+	}
+
+	// Write the header, index and string table, then the checksum of all three
+	header := make([]byte, v2HeaderSize)
+	copy(header, v2Magic)
+	binary.LittleEndian.PutUint16(header[4:], Version2)
+	binary.LittleEndian.PutUint16(header[6:], uint16(hashLen))      //nolint:gosec
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(entries))) //nolint:gosec
+	binary.LittleEndian.PutUint32(header[12:], uint32(table.Len())) //nolint:gosec
+
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(w, crc)
+	for _, section := range [][]byte{header, index.Bytes(), table.Bytes()} {
+		if _, err := out.Write(section); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	if err := binary.Write(w, binary.LittleEndian, crc.Sum32()); err != nil {
+		return fmt.Errorf("failed to write checksum: %w", err)
+	}
+
+	return nil
+}
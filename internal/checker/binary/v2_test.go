@@ -0,0 +1,228 @@
+package binary
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testV2Records are written in unsorted order to check that WriteV2 sorts them.
+var testV2Records = []Record{ //nolint:gochecknoglobals
+	{Hash: "ffff0000", Status: "banned", Reason: "violation", Confidence: 0.95},
+	{Hash: "00000001", Status: "flagged", Reason: "spam", Confidence: 0.5},
+	{Hash: "7f7f7f7f", Status: "banned", Reason: "violation", Confidence: 0.75},
+}
+
+func writeV2File(t *testing.T, path string, records []Record) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, WriteV2(&buf, 4, records))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+	return buf.Bytes()
+}
+
+func TestWriteV2_Layout(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteV2(&buf, 4, testV2Records))
+	data := buf.Bytes()
+
+	h, err := parseV2Header(data, int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, 4, h.hashLen)
+	assert.Equal(t, uint32(3), h.count)
+
+	// Repeated strings are stored once: banned, violation, flagged and spam
+	assert.Equal(t, uint32(4*2+len("banned")+len("violation")+len("flagged")+len("spam")), h.tableSize)
+
+	// The footer holds the checksum of the rest of the file
+	assert.Equal(t, crc32.ChecksumIEEE(data[:len(data)-4]), binary.LittleEndian.Uint32(data[len(data)-4:]))
+}
+
+func TestWriteV2_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashLen int
+		records []Record
+		wantErr string
+	}{
+		{name: "Invalid hash length", hashLen: 0, wantErr: "invalid hash length"},
+		{name: "Invalid hex", hashLen: 4, records: []Record{{Hash: "zzzzzzzz"}}, wantErr: "invalid hash format"},
+		{name: "Wrong hash length", hashLen: 4, records: []Record{{Hash: "00"}}, wantErr: "has 1 bytes, expected 4"},
+		{
+			name:    "Duplicate hash",
+			hashLen: 4,
+			records: []Record{{Hash: "00000001"}, {Hash: "00000001"}},
+			wantErr: "duplicate hash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WriteV2(&bytes.Buffer{}, tt.hashLen, tt.records)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestChecker_V2(t *testing.T) {
+	tempDir := t.TempDir()
+	writeV2File(t, filepath.Join(tempDir, "users.bin"), testV2Records)
+	writeV2File(t, filepath.Join(tempDir, "groups.bin"), nil)
+
+	checker := New(tempDir)
+	defer checker.Close()
+
+	version, err := checker.Version(common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, Version2, version)
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+
+	tests := []struct {
+		name       string
+		checkType  common.CheckType
+		hash       string
+		wantFound  bool
+		wantStatus string
+		wantReason string
+	}{
+		{name: "First entry", checkType: common.CheckTypeUser, hash: "00000001", wantFound: true, wantStatus: "flagged", wantReason: "spam"},
+		{name: "Middle entry", checkType: common.CheckTypeUser, hash: "7f7f7f7f", wantFound: true, wantStatus: "banned", wantReason: "violation"},
+		{name: "Last entry", checkType: common.CheckTypeUser, hash: "FFFF0000", wantFound: true, wantStatus: "banned", wantReason: "violation"},
+		{name: "Before first entry", checkType: common.CheckTypeUser, hash: "00000000"},
+		{name: "Between entries", checkType: common.CheckTypeUser, hash: "80000000"},
+		{name: "After last entry", checkType: common.CheckTypeUser, hash: "ffffffff"},
+		{name: "Empty file", checkType: common.CheckTypeGroup, hash: "00000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, result.Found)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Equal(t, tt.wantReason, result.Reason)
+		})
+	}
+
+	t.Run("CheckMany", func(t *testing.T) {
+		results, err := checker.CheckMany(context.Background(), common.CheckTypeUser,
+			[]string{"7f7f7f7f", "00000000", "00000001", "7f7f7f7f"})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		assert.True(t, results[0].Found)
+		assert.InDelta(t, 0.75, results[0].Confidence, 0.001)
+		assert.False(t, results[1].Found)
+		assert.Equal(t, "flagged", results[2].Status)
+		assert.True(t, results[3].Found)
+	})
+
+	t.Run("Wrong hash length", func(t *testing.T) {
+		_, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
+		assert.ErrorContains(t, err, "invalid hash format")
+	})
+}
+
+func TestChecker_V1Version(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	version, err := New(tempDir).Version(common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, Version1, version)
+}
+
+func TestChecker_V2Corrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+		wantErr error
+	}{
+		{
+			name: "Checksum mismatch",
+			corrupt: func(data []byte) []byte {
+				data[v2HeaderSize] ^= 0xff
+				return data
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "Unsupported version",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint16(data[4:], 3)
+				return data
+			},
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name: "Truncated",
+			corrupt: func(data []byte) []byte {
+				return data[:len(data)-1]
+			},
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			path := filepath.Join(tempDir, "users.bin")
+			data := writeV2File(t, path, testV2Records)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(data), 0o600))
+
+			_, err := New(tempDir).Check(context.Background(), common.CheckTypeUser, "00000001")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWalk_V2(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "users.bin")
+	data := writeV2File(t, path, testV2Records)
+
+	var hashes []string
+	collect := func(hash string, result *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		assert.True(t, result.Found)
+		return nil
+	}
+
+	// The hash length of the header is used instead of the one passed in
+	problems, err := Walk(tempDir, common.CheckTypeUser, 32, collect)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, []string{"00000001", "7f7f7f7f", "ffff0000"}, hashes)
+
+	// Swap the first two entries so the index is out of order
+	entrySize := 4 + 16
+	first := bytes.Clone(data[v2HeaderSize : v2HeaderSize+entrySize])
+	copy(data[v2HeaderSize:], data[v2HeaderSize+entrySize:v2HeaderSize+2*entrySize])
+	copy(data[v2HeaderSize+entrySize:], first)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	hashes = nil
+	problems, err = Walk(tempDir, common.CheckTypeUser, 32, collect)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 3)
+
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	assert.Contains(t, joined, ErrChecksumMismatch.Error())
+	assert.Contains(t, joined, ErrUnsortedIndex.Error())
+}
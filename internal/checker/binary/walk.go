@@ -0,0 +1,192 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Walk fully parses the binary file for the check type, calling fn with the hex hash of every valid record.
+// Version 1 records are expected to hold hashes of hashLen bytes, while version 2 files use the hash length
+// in their header. Invalid field values and records rejected by fn are returned as problems with the byte
+// offset of their record. Since version 1 records have no framing, parsing stops at the first record that
+// runs past the end of the file. An error is returned if the file cannot be read at all, or if fn returns
+// common.ErrStopWalk.
+func Walk(dir string, checkType common.CheckType, hashLen int, fn common.RecordFunc) ([]error, error) {
+	// Determine filename based on check type
+	filename := "users.bin"
+	if checkType == common.CheckTypeGroup {
+		filename = "groups.bin"
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if isV2(data) {
+		return walkV2(data, fn)
+	}
+	return walkV1(data, hashLen, fn)
+}
+
+// walkV1 parses the records of a version 1 file.
+func walkV1(data []byte, hashLen int, fn common.RecordFunc) ([]error, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	offset := 4
+
+	var problems []error
+	for i := range count {
+		record, next, err := parseV1Record(data, offset, hashLen)
+		if err != nil {
+			return append(problems, fmt.Errorf("offset %d: record %d: %w", offset, i, err)), nil
+		}
+		recordOffset := offset
+		offset = next
+
+		hash := hex.EncodeToString(record.hash)
+		if err := common.ValidateRecord(hash, record.confidence); err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", recordOffset, err))
+			continue
+		}
+
+		err = fn(hash, record.result())
+		if errors.Is(err, common.ErrStopWalk) {
+			return problems, err
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", recordOffset, err))
+		}
+	}
+
+	if offset != len(data) {
+		problems = append(problems, fmt.Errorf("offset %d: %w: %d unexpected bytes after last record",
+			offset, ErrInvalidFormat, len(data)-offset))
+	}
+
+	return problems, nil
+}
+
+// v1Record is a record of a version 1 file. Its fields point into the file data.
+type v1Record struct {
+	hash       []byte
+	status     []byte
+	reason     []byte
+	confidence float64
+}
+
+// result copies the record into a check result.
+func (r *v1Record) result() *common.CheckResult {
+	return &common.CheckResult{
+		Found:      true,
+		Status:     string(r.status),
+		Reason:     string(r.reason),
+		Confidence: r.confidence,
+	}
+}
+
+// parseV1Record parses the version 1 record at offset without copying it, returning the offset of the
+// next record. An error is returned if the record runs past the end of the data.
+func parseV1Record(data []byte, offset, hashLen int) (v1Record, int, error) {
+	var record v1Record
+
+	// Read hash
+	if offset+hashLen > len(data) {
+		return record, 0, fmt.Errorf("%w: hash runs past end of file", ErrInvalidFormat)
+	}
+	record.hash = data[offset : offset+hashLen]
+	offset += hashLen
+
+	// Read status and reason
+	var err error
+	if record.status, offset, err = parseV1String(data, offset, "status"); err != nil {
+		return record, 0, err
+	}
+	if record.reason, offset, err = parseV1String(data, offset, "reason"); err != nil {
+		return record, 0, err
+	}
+
+	// Read confidence
+	if offset+8 > len(data) {
+		return record, 0, fmt.Errorf("%w: confidence runs past end of file", ErrInvalidFormat)
+	}
+	record.confidence = math.Float64frombits(binary.LittleEndian.Uint64(data[offset:]))
+	offset += 8
+
+	return record, offset, nil
+}
+
+// parseV1String parses the length-prefixed string at offset, returning the offset after it.
+func parseV1String(data []byte, offset int, fieldName string) ([]byte, int, error) {
+	if offset+2 > len(data) {
+		return nil, 0, fmt.Errorf("%w: %s length runs past end of file", ErrInvalidFormat, fieldName)
+	}
+	length := int(binary.LittleEndian.Uint16(data[offset:]))
+	offset += 2
+
+	if offset+length > len(data) {
+		return nil, 0, fmt.Errorf("%w: %s runs past end of file", ErrInvalidFormat, fieldName)
+	}
+	return data[offset : offset+length], offset + length, nil
+}
+
+// walkV2 parses the index entries of a version 2 file.
+// A checksum mismatch and entries out of order are reported as problems.
+func walkV2(data []byte, fn common.RecordFunc) ([]error, error) {
+	h, err := parseV2Header(data, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(data)
+	var problems []error
+	if err := verifyChecksum(r, int64(len(data))); err != nil {
+		problems = append(problems, fmt.Errorf("offset %d: %w", len(data)-v2FooterSize, err))
+	}
+
+	var prev []byte
+	for i := range int(h.count) { //nolint:gosec
+		offset := h.entryOffset(i)
+		entry := data[offset : offset+h.entrySize()]
+		hash := entry[:h.hashLen]
+
+		// Lookups binary-search the index, so it must be strictly ascending
+		if prev != nil && bytes.Compare(prev, hash) >= 0 {
+			problems = append(problems, fmt.Errorf("offset %d: %w: entry %d is not after the previous entry",
+				offset, ErrUnsortedIndex, i))
+		}
+		prev = hash
+
+		result, err := h.readEntry(r, entry)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: entry %d: %w", offset, i, err))
+			continue
+		}
+
+		hexHash := hex.EncodeToString(hash)
+		if err := common.ValidateRecord(hexHash, result.Confidence); err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", offset, err))
+			continue
+		}
+
+		err = fn(hexHash, result)
+		if errors.Is(err, common.ErrStopWalk) {
+			return problems, err
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", offset, err))
+		}
+	}
+
+	return problems, nil
+}
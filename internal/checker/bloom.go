@@ -0,0 +1,167 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+var (
+	ErrCorruptRecords   = errors.New("export has corrupt records")
+	ErrBloomMissingHash = errors.New("bloom filter is missing hashes")
+	ErrBloomStale       = errors.New("bloom filter does not match the storage file")
+)
+
+// FilteredChecker is implemented by checkers that consult bloom filters before the storage files.
+type FilteredChecker interface {
+	Checker
+	BloomFilter(checkType common.CheckType) *bloom.Filter
+}
+
+// filteredChecker answers lookups of hashes that the bloom filter rules out without touching the storage files.
+type filteredChecker struct {
+	Checker
+	filters  map[common.CheckType]*bloom.Filter
+	warnings []error
+}
+
+// withBloomFilters wraps the checker with the bloom filters found in the export directory.
+// Filters generated from other storage files, or other versions of the storage file, are ignored, since
+// they may be missing hashes in it, and reported as warnings. The checker is returned unchanged if the export has no filters.
+func withBloomFilters(dir string, storageType common.StorageType, c Checker) (Checker, error) {
+	validator := NewValidator()
+	filters := make(map[common.CheckType]*bloom.Filter)
+	var warnings []error
+	for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+		filter, err := bloom.Load(dir, checkType)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", bloom.Filename(checkType), err)
+		}
+
+		filename, err := validator.findFile(dir, checkType, storageType)
+		if err != nil {
+			continue // Exports may contain only one check type
+		}
+		if !filter.Matches(dir, filename) {
+			warnings = append(warnings, fmt.Errorf("%w: %s was not generated from the current %s, "+
+				"regenerate it with 'rotten bloom'", ErrBloomStale, bloom.Filename(checkType), filename))
+			continue
+		}
+		filters[checkType] = filter
+	}
+
+	if len(filters) == 0 && len(warnings) == 0 {
+		return c, nil
+	}
+	return &filteredChecker{Checker: c, filters: filters, warnings: warnings}, nil
+}
+
+// Warnings returns the bloom filters that were ignored because they do not match the storage files.
+func (c *filteredChecker) Warnings() []error {
+	return c.warnings
+}
+
+// BloomFilter returns the bloom filter for the check type, or nil if there is none.
+func (c *filteredChecker) BloomFilter(checkType common.CheckType) *bloom.Filter {
+	if checkType != common.CheckTypeGroup {
+		checkType = common.CheckTypeUser
+	}
+	return c.filters[checkType]
+}
+
+// Check looks up the hash in the storage files unless the bloom filter rules it out.
+func (c *filteredChecker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	filter := c.BloomFilter(checkType)
+	if filter == nil || filter.Test(id) {
+		return c.Checker.Check(ctx, checkType, id)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &common.CheckResult{}, nil
+}
+
+// CheckMany looks up the hashes that the bloom filter does not rule out in the storage files.
+// The results are in the same order as the hashes.
+func (c *filteredChecker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	filter := c.BloomFilter(checkType)
+	if filter == nil {
+		return c.Checker.CheckMany(ctx, checkType, hashes)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keep the hashes that may be in the export
+	results := make([]*common.CheckResult, len(hashes))
+	var candidates []string
+	var positions []int
+	for i, hash := range hashes {
+		if filter.Test(hash) {
+			candidates = append(candidates, hash)
+			positions = append(positions, i)
+		} else {
+			results[i] = &common.CheckResult{}
+		}
+	}
+	if len(candidates) == 0 {
+		return results, nil
+	}
+
+	found, err := c.Checker.CheckMany(ctx, checkType, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range found {
+		results[positions[i]] = result
+	}
+
+	return results, nil
+}
+
+// GenerateBloomFilter builds a bloom filter of every hash in the storage file for the check type.
+// Exports with corrupt records are refused, since a filter missing their hashes would hide them from lookups.
+func GenerateBloomFilter(
+	dir string, checkType common.CheckType, storageType common.StorageType, falsePositiveRate float64,
+) (*bloom.Filter, error) {
+	// Fingerprint the storage file before reading it, so that a change during generation makes the filter stale
+	filename, err := NewValidator().findFile(dir, checkType, storageType)
+	if err != nil {
+		return nil, err
+	}
+	source, err := bloom.Fingerprint(dir, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []string
+	problems, err := walkFile(dir, checkType, storageType, func(hash string, _ *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %d corrupt records, first: %w", ErrCorruptRecords, len(problems), problems[0])
+	}
+
+	filter, err := bloom.New(uint64(len(hashes)), falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range hashes {
+		filter.Add(hash)
+	}
+	filter.SetSource(source)
+
+	return filter, nil
+}
@@ -0,0 +1,283 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Bloom filter files hold a filter of the hashes in an export, so that lookups of hashes that are
+// not in the export can skip the storage files. All integers are little-endian:
+//
+//	header   magic "RTBF" | version uint16 | hash function count uint16 | hash count uint64 | bit count uint64
+//	source   the storage file the filter was generated from:
+//	         name length uint16 | name | size uint64 | modification time int64 (Unix nanoseconds)
+//	bits     the filter bits, rounded up to whole bytes
+//	footer   CRC-32 (IEEE) of everything before the footer
+//
+// Version 1 files have no source section, so they cannot be matched to the storage files.
+const (
+	Version = 2
+
+	versionNoSource = 1
+
+	magic      = "RTBF"
+	headerSize = 24
+	footerSize = 4
+
+	// DefaultFalsePositiveRate is the false-positive rate used when generating filters.
+	DefaultFalsePositiveRate = 0.01
+)
+
+var (
+	ErrInvalidFormat            = errors.New("invalid bloom filter format")
+	ErrInvalidFalsePositiveRate = errors.New("false-positive rate must be between 0 and 1")
+)
+
+// Filename returns the name of the bloom filter file for the check type.
+func Filename(checkType common.CheckType) string {
+	if checkType == common.CheckTypeGroup {
+		return "groups.bloom"
+	}
+	return "users.bloom"
+}
+
+// Source fingerprints the storage file a filter was generated from.
+type Source struct {
+	Name    string
+	Size    int64
+	ModTime int64 // Unix nanoseconds
+}
+
+// Fingerprint returns the source of the named storage file in the directory.
+func Fingerprint(dir, filename string) (Source, error) {
+	info, err := os.Stat(filepath.Join(dir, filename))
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to get file stats: %w", err)
+	}
+	return Source{Name: filename, Size: info.Size(), ModTime: info.ModTime().UnixNano()}, nil
+}
+
+// Filter is a bloom filter of export hashes. Hashes are matched exactly, like the storage files match them.
+type Filter struct {
+	bits   []byte
+	m      uint64 // Number of bits
+	k      uint16 // Number of hash functions
+	hashes uint64 // Number of hashes added
+	source Source // Storage file the filter was generated from
+}
+
+// New creates a filter sized to hold n hashes with the given false-positive rate.
+func New(n uint64, falsePositiveRate float64) (*Filter, error) {
+	if math.IsNaN(falsePositiveRate) || falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFalsePositiveRate, falsePositiveRate)
+	}
+
+	// Optimal bit and hash function counts for n items
+	items := math.Max(float64(n), 1)
+	m := uint64(math.Ceil(-items * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint16(math.Max(1, math.Round(float64(m)/items*math.Ln2)))
+
+	return &Filter{
+		bits: make([]byte, (m+7)/8),
+		m:    m,
+		k:    k,
+	}, nil
+}
+
+// locations returns the two base hashes of a key, combined to derive the bit of each hash function.
+func locations(key string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(key))
+	sum := h.Sum(nil)
+	return binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:]) | 1
+}
+
+// Add adds a hash to the filter.
+func (f *Filter) Add(key string) {
+	h1, h2 := locations(key)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+	f.hashes++
+}
+
+// Test reports whether the hash may be in the filter. A false result means it is definitely not.
+func (f *Filter) Test(key string) bool {
+	h1, h2 := locations(key)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSource records the storage file the filter was generated from.
+func (f *Filter) SetSource(source Source) {
+	f.source = source
+}
+
+// Source returns the storage file the filter was generated from. Its name is empty if it is unknown.
+func (f *Filter) Source() Source {
+	return f.source
+}
+
+// Matches reports whether the filter was generated from the named storage file in the directory, and
+// the file is unchanged since. A filter that does not match may be missing hashes in the file.
+func (f *Filter) Matches(dir, filename string) bool {
+	if f.source.Name != filename {
+		return false
+	}
+	current, err := Fingerprint(dir, filename)
+	return err == nil && current == f.source
+}
+
+// Count returns the number of hashes added to the filter.
+func (f *Filter) Count() uint64 {
+	return f.hashes
+}
+
+// Size returns the size of the filter bits in bytes.
+func (f *Filter) Size() int {
+	return len(f.bits)
+}
+
+// FalsePositiveRate returns the expected rate of hashes not in the filter that Test still accepts.
+func (f *Filter) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.hashes)/float64(f.m)), float64(f.k))
+}
+
+// WriteTo writes the filter in the bloom filter file format.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.LittleEndian.PutUint16(header[4:], Version)
+	binary.LittleEndian.PutUint16(header[6:], f.k)
+	binary.LittleEndian.PutUint64(header[8:], f.hashes)
+	binary.LittleEndian.PutUint64(header[16:], f.m)
+
+	source := binary.LittleEndian.AppendUint16(nil, uint16(len(f.source.Name)))
+	source = append(source, f.source.Name...)
+	source = binary.LittleEndian.AppendUint64(source, uint64(f.source.Size))
+	source = binary.LittleEndian.AppendUint64(source, uint64(f.source.ModTime))
+
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(w, crc)
+	var written int64
+	for _, section := range [][]byte{header, source, f.bits} {
+		n, err := out.Write(section)
+		written += int64(n)
+		if err != nil {
+			return written, fmt.Errorf("failed to write bloom filter: %w", err)
+		}
+	}
+
+	footer := binary.LittleEndian.AppendUint32(nil, crc.Sum32())
+	n, err := w.Write(footer)
+	written += int64(n)
+	if err != nil {
+		return written, fmt.Errorf("failed to write bloom filter: %w", err)
+	}
+	return written, nil
+}
+
+// Save writes the filter for the check type to the export directory.
+func (f *Filter) Save(dir string, checkType common.CheckType) error {
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, Filename(checkType)), buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to save bloom filter: %w", err)
+	}
+	return nil
+}
+
+// Load reads the filter for the check type from the export directory.
+// If the export has no filter, the returned error wraps os.ErrNotExist.
+func Load(dir string, checkType common.CheckType) (*Filter, error) {
+	data, err := os.ReadFile(filepath.Join(dir, Filename(checkType)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bloom filter: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a filter in the bloom filter file format.
+func Parse(data []byte) (*Filter, error) {
+	if len(data) < headerSize+footerSize {
+		return nil, fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	}
+	if string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: missing magic", ErrInvalidFormat)
+	}
+	version := binary.LittleEndian.Uint16(data[4:])
+	if version != Version && version != versionNoSource {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+
+	f := &Filter{
+		k:      binary.LittleEndian.Uint16(data[6:]),
+		hashes: binary.LittleEndian.Uint64(data[8:]),
+		m:      binary.LittleEndian.Uint64(data[16:]),
+	}
+	if f.k == 0 {
+		return nil, fmt.Errorf("%w: empty filter parameters", ErrInvalidFormat)
+	}
+
+	bits := data[headerSize : len(data)-footerSize]
+	if version != versionNoSource {
+		source, rest, err := parseSource(bits)
+		if err != nil {
+			return nil, err
+		}
+		f.source, bits = source, rest
+	}
+
+	// The bits must exactly fill the space between the source and footer. The bit count is checked against
+	// the bytes present first, since rounding a corrupt count up to whole bytes could overflow.
+	if f.m == 0 || f.m > uint64(len(bits))*8 {
+		return nil, fmt.Errorf("%w: %d bits do not fit in %d bytes", ErrInvalidFormat, f.m, len(bits))
+	}
+	if uint64(len(bits)) != (f.m+7)/8 {
+		return nil, fmt.Errorf("%w: %d bytes of bits, expected %d", ErrInvalidFormat, len(bits), (f.m+7)/8)
+	}
+
+	expected := binary.LittleEndian.Uint32(data[len(data)-footerSize:])
+	if sum := crc32.ChecksumIEEE(data[:len(data)-footerSize]); sum != expected {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidFormat)
+	}
+
+	f.bits = bits
+	return f, nil
+}
+
+// parseSource parses the source section at the start of data and returns the data after it.
+func parseSource(data []byte) (Source, []byte, error) {
+	if len(data) < 2 {
+		return Source{}, nil, fmt.Errorf("%w: truncated source", ErrInvalidFormat)
+	}
+	nameLen := int(binary.LittleEndian.Uint16(data))
+	if len(data) < 2+nameLen+16 {
+		return Source{}, nil, fmt.Errorf("%w: truncated source", ErrInvalidFormat)
+	}
+
+	source := Source{
+		Name:    string(data[2 : 2+nameLen]),
+		Size:    int64(binary.LittleEndian.Uint64(data[2+nameLen:])),
+		ModTime: int64(binary.LittleEndian.Uint64(data[10+nameLen:])),
+	}
+	return source, data[2+nameLen+16:], nil
+}
@@ -0,0 +1,151 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_InvalidFalsePositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, 1, -0.5, 2} {
+		_, err := New(10, rate)
+		assert.ErrorIs(t, err, ErrInvalidFalsePositiveRate, "rate %v", rate)
+	}
+}
+
+func TestFilter_AddTest(t *testing.T) {
+	const n = 10000
+	filter, err := New(n, 0.01)
+	require.NoError(t, err)
+
+	for i := range n {
+		filter.Add(fmt.Sprintf("%064x", i))
+	}
+	assert.Equal(t, uint64(n), filter.Count())
+	assert.InDelta(t, 0.01, filter.FalsePositiveRate(), 0.002)
+
+	// Added hashes are always accepted
+	for i := range n {
+		require.True(t, filter.Test(fmt.Sprintf("%064x", i)))
+	}
+
+	// Other hashes are rejected at roughly the expected rate
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if filter.Test(fmt.Sprintf("%064x", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, n/50)
+}
+
+func TestFilter_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	filter, err := New(100, 0.05)
+	require.NoError(t, err)
+	filter.Add("aa")
+	filter.Add("bb")
+	require.NoError(t, filter.Save(dir, common.CheckTypeGroup))
+
+	loaded, err := Load(dir, common.CheckTypeGroup)
+	require.NoError(t, err)
+	assert.Equal(t, filter, loaded)
+	assert.True(t, loaded.Test("aa"))
+
+	_, err = Load(dir, common.CheckTypeUser)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFilter_Matches(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte("hash\n"), 0o600))
+
+	filter, err := New(10, 0.01)
+	require.NoError(t, err)
+	source, err := Fingerprint(dir, "users.csv")
+	require.NoError(t, err)
+	filter.SetSource(source)
+	require.NoError(t, filter.Save(dir, common.CheckTypeUser))
+
+	loaded, err := Load(dir, common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, source, loaded.Source())
+	assert.True(t, loaded.Matches(dir, "users.csv"))
+
+	// Other storage files do not match, even when identical to the source
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.jsonl"), []byte("hash\n"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "users.jsonl"), time.Time{}, time.Unix(0, source.ModTime)))
+	assert.False(t, loaded.Matches(dir, "users.jsonl"))
+	assert.False(t, loaded.Matches(dir, "users.db"))
+
+	// Rewriting the storage file makes the filter stale
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte("hash\naa\n"), 0o600))
+	assert.False(t, loaded.Matches(dir, "users.csv"))
+}
+
+func TestParse_Errors(t *testing.T) {
+	filter, err := New(10, 0.01)
+	require.NoError(t, err)
+	filter.Add("aa")
+	var buf bytes.Buffer
+	_, err = filter.WriteTo(&buf)
+	require.NoError(t, err)
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name    string
+		data    func() []byte
+		wantErr string
+	}{
+		{name: "Too small", data: func() []byte { return valid[:10] }, wantErr: "file too small"},
+		{name: "Missing magic", data: func() []byte {
+			data := bytes.Clone(valid)
+			data[0] = 'X'
+			return data
+		}, wantErr: "missing magic"},
+		{name: "Truncated bits", data: func() []byte {
+			return append(bytes.Clone(valid[:len(valid)-5]), valid[len(valid)-4:]...)
+		}, wantErr: "do not fit"},
+		{name: "Extra bits", data: func() []byte {
+			data := bytes.Clone(valid[:len(valid)-footerSize])
+			return append(append(data, 0), valid[len(valid)-footerSize:]...)
+		}, wantErr: "bytes of bits"},
+		{name: "Zero bit count", data: func() []byte {
+			data := bytes.Clone(valid)
+			binary.LittleEndian.PutUint64(data[16:], 0)
+			return data
+		}, wantErr: "do not fit"},
+		{name: "Huge bit count", data: func() []byte {
+			data := bytes.Clone(valid)
+			binary.LittleEndian.PutUint64(data[16:], math.MaxUint64)
+			return data
+		}, wantErr: "do not fit"},
+		{name: "Checksum mismatch", data: func() []byte {
+			data := bytes.Clone(valid)
+			data[len(data)-footerSize-1] ^= 0xff
+			return data
+		}, wantErr: "checksum mismatch"},
+		{name: "Truncated source", data: func() []byte {
+			data := bytes.Clone(valid)
+			data[headerSize] = 0xff
+			return data
+		}, wantErr: "truncated source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data())
+			require.ErrorIs(t, err, ErrInvalidFormat)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
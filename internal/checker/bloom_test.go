@@ -0,0 +1,179 @@
+package checker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingChecker records the hashes looked up in the storage files.
+type countingChecker struct {
+	Checker
+	lookups []string
+}
+
+func (c *countingChecker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	c.lookups = append(c.lookups, id)
+	return c.Checker.Check(ctx, checkType, id)
+}
+
+func (c *countingChecker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	c.lookups = append(c.lookups, hashes...)
+	return c.Checker.CheckMany(ctx, checkType, hashes)
+}
+
+func TestFilteredChecker(t *testing.T) {
+	dir := setupVerifyExport(t)
+
+	filter, err := GenerateBloomFilter(dir, common.CheckTypeUser, common.StorageTypeCSV, 0.0001)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), filter.Count())
+	require.NoError(t, filter.Save(dir, common.CheckTypeUser))
+	assert.True(t, NewValidator().HasBloomFilter(dir, common.CheckTypeUser))
+	assert.False(t, NewValidator().HasBloomFilter(dir, common.CheckTypeGroup))
+
+	c, err := New(dir, common.StorageTypeCSV)
+	require.NoError(t, err)
+	defer c.Close()
+	filtered, ok := c.(*filteredChecker)
+	require.True(t, ok)
+	assert.Same(t, filtered.BloomFilter(common.CheckTypeUser), filtered.BloomFilter(common.CheckTypeFriends))
+	assert.Nil(t, filtered.BloomFilter(common.CheckTypeGroup))
+
+	// Indexed checkers hold every hash in memory and do not use the filters
+	indexedChecker, err := New(dir, common.StorageTypeCSVIndexed)
+	require.NoError(t, err)
+	defer indexedChecker.Close()
+	_, ok = indexedChecker.(FilteredChecker)
+	assert.False(t, ok)
+
+	// Hashes ruled out by the filter skip the storage files
+	counting := &countingChecker{Checker: filtered.Checker}
+	filtered.Checker = counting
+	ctx := context.Background()
+
+	result, err := c.Check(ctx, common.CheckTypeUser, testHash(1))
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+
+	result, err = c.Check(ctx, common.CheckTypeUser, testHash(4))
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Equal(t, []string{testHash(1)}, counting.lookups)
+
+	counting.lookups = nil
+	results, err := c.CheckMany(ctx, common.CheckTypeUser, []string{testHash(4), testHash(2), testHash(5)})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Found)
+	assert.Equal(t, "flagged", results[1].Status)
+	assert.False(t, results[2].Found)
+	assert.Equal(t, []string{testHash(2)}, counting.lookups)
+
+	// Group lookups have no filter and always reach the storage files
+	counting.lookups = nil
+	results, err = c.CheckMany(ctx, common.CheckTypeGroup, []string{testHash(3), testHash(4)})
+	require.NoError(t, err)
+	assert.True(t, results[0].Found)
+	assert.Equal(t, []string{testHash(3), testHash(4)}, counting.lookups)
+}
+
+func TestNew_StaleBloomFilter(t *testing.T) {
+	dir := setupVerifyExport(t)
+
+	filter, err := GenerateBloomFilter(dir, common.CheckTypeUser, common.StorageTypeCSV, 0.0001)
+	require.NoError(t, err)
+	require.NoError(t, filter.Save(dir, common.CheckTypeUser))
+
+	// The filter is not used with the other storage files, which may hold other hashes
+	sqliteChecker, err := New(dir, common.StorageTypeSQLite)
+	require.NoError(t, err)
+	defer sqliteChecker.Close()
+	warnings := Warnings(sqliteChecker)
+	require.Len(t, warnings, 1)
+	assert.ErrorIs(t, warnings[0], ErrBloomStale)
+	assert.Contains(t, warnings[0].Error(), "users.db")
+	assert.Nil(t, sqliteChecker.(FilteredChecker).BloomFilter(common.CheckTypeUser))
+
+	// A hash flagged after the filter was generated must still be found
+	content := "hash,status,reason,confidence\n" +
+		testHash(1) + ",confirmed,a; b,0.9\n" +
+		testHash(2) + ",flagged,c,0.5\n" +
+		testHash(4) + ",flagged,new,0.7\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	c, err := New(dir, common.StorageTypeCSV)
+	require.NoError(t, err)
+	defer c.Close()
+
+	warnings = Warnings(c)
+	require.Len(t, warnings, 1)
+	assert.ErrorIs(t, warnings[0], ErrBloomStale)
+	assert.Nil(t, c.(FilteredChecker).BloomFilter(common.CheckTypeUser))
+
+	result, err := c.Check(context.Background(), common.CheckTypeUser, testHash(4))
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+}
+
+func TestNew_InvalidBloomFilter(t *testing.T) {
+	dir := setupVerifyExport(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, bloom.Filename(common.CheckTypeUser)), []byte("not a filter"), 0o600))
+
+	_, err := New(dir, common.StorageTypeSQLite)
+	assert.ErrorIs(t, err, bloom.ErrInvalidFormat)
+}
+
+func TestGenerateBloomFilter_CorruptRecords(t *testing.T) {
+	dir := setupVerifyExport(t)
+	content := "hash,status,reason,confidence\n" + testHash(1) + ",flagged,c,high\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	_, err := GenerateBloomFilter(dir, common.CheckTypeUser, common.StorageTypeCSV, bloom.DefaultFalsePositiveRate)
+	assert.ErrorIs(t, err, ErrCorruptRecords)
+}
+
+func TestGenerateBloomFilter_UppercaseHash(t *testing.T) {
+	dir := setupVerifyExport(t)
+	content := "hash,status,reason,confidence\n" + strings.ToUpper(testHash(10)) + ",flagged,c,0.5\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	// Storage lookups match hashes exactly, so the filter must not accept other cases either
+	_, err := GenerateBloomFilter(dir, common.CheckTypeUser, common.StorageTypeCSV, bloom.DefaultFalsePositiveRate)
+	assert.ErrorIs(t, err, ErrCorruptRecords)
+	assert.ErrorIs(t, err, common.ErrInvalidRecordHash)
+}
+
+func TestValidator_Verify_BloomFilter(t *testing.T) {
+	dir := setupVerifyExport(t)
+
+	filter, err := GenerateBloomFilter(dir, common.CheckTypeUser, common.StorageTypeSQLite, 0.0001)
+	require.NoError(t, err)
+	require.NoError(t, filter.Save(dir, common.CheckTypeUser))
+
+	report := NewValidator().Verify(dir)
+	assert.True(t, report.OK())
+	require.Len(t, report.BloomFilters, 1)
+	assert.True(t, report.BloomFilters[0].OK())
+	assert.Equal(t, uint64(2), report.BloomFilters[0].Hashes)
+
+	// A filter generated before a hash was added would hide it from lookups
+	stale, err := bloom.New(2, 0.0001)
+	require.NoError(t, err)
+	stale.Add(testHash(1))
+	require.NoError(t, stale.Save(dir, common.CheckTypeUser))
+
+	report = NewValidator().Verify(dir)
+	assert.False(t, report.OK())
+	require.Len(t, report.BloomFilters, 1)
+	require.Len(t, report.BloomFilters[0].Missing, 1)
+	assert.ErrorIs(t, report.BloomFilters[0].Missing[0], ErrBloomMissingHash)
+	assert.Contains(t, report.BloomFilters[0].Missing[0].Error(), testHash(2))
+}
@@ -0,0 +1,38 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/checker/cdb"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/hasher"
+)
+
+// GenerateCDB writes every record in the storage file for the check type to a constant database file in
+// the export directory, returning the number of records written. Only the first record of a duplicated hash
+// is written. Exports with corrupt records are refused, since lookups in the new file would not find their hashes.
+func GenerateCDB(dir string, checkType common.CheckType, storageType common.StorageType) (int, error) {
+	var records []cdb.Record
+	problems, err := walkFile(dir, checkType, storageType, func(hash string, result *common.CheckResult) error {
+		records = append(records, cdb.Record{
+			Hash:       hash,
+			Status:     result.Status,
+			Reason:     result.Reason,
+			Confidence: result.Confidence,
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%w: %d corrupt records, first: %w", ErrCorruptRecords, len(problems), problems[0])
+	}
+
+	// Records are validated as hex, so the hash length is half the length of any of them
+	hashLen := hasher.Size
+	if len(records) > 0 {
+		hashLen = len(records[0].Hash) / 2
+	}
+	return cdb.Save(dir, checkType, hashLen, records)
+}
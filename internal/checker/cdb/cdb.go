@@ -0,0 +1,444 @@
+package cdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Constant database files hold the records of an export in an on-disk hash table, so a lookup reads a
+// few neighbouring slots and then the record data, whatever the size of the export. The files are
+// written once and never modified. All integers are little-endian:
+//
+//	header  magic "RTCD" | version uint16 | hash length uint16 | record count uint32 | slot count uint32 |
+//	        data size uint32
+//	slots   slot count entries, a power of two at least twice the record count:
+//	        hash | data offset uint32 | data length uint32 | confidence float64
+//	data    the status and reason of each distinct pair: length uint16 | status | length uint16 | reason
+//	footer  CRC-32 (IEEE) of everything before the footer
+//
+// A hash belongs in the slot given by its FNV-1a hash modulo the slot count, or the first empty slot
+// after it (wrapping around). Empty slots have a data offset of 0xFFFFFFFF.
+const (
+	Version = 1
+
+	magic      = "RTCD"
+	headerSize = 20
+	footerSize = 4
+
+	// emptySlot is the data offset of a slot without a record.
+	emptySlot = math.MaxUint32
+
+	// probeWindow is the number of slots read at once, which usually covers the whole probe sequence.
+	probeWindow = 4
+
+	// ctxCheckInterval is the number of records read between context checks.
+	ctxCheckInterval = 1024
+)
+
+var (
+	ErrInvalidFormat    = errors.New("invalid constant database format")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// Filename returns the name of the constant database file for the check type.
+func Filename(checkType common.CheckType) string {
+	if checkType == common.CheckTypeGroup {
+		return "groups.cdb"
+	}
+	return "users.cdb"
+}
+
+// header describes the layout of a file.
+type header struct {
+	hashLen  int
+	count    uint32
+	slots    uint32
+	dataSize uint32
+}
+
+// slotSize returns the size of a slot.
+func (h *header) slotSize() int64 {
+	return int64(h.hashLen) + 16
+}
+
+// slotOffset returns the file offset of the i-th slot.
+func (h *header) slotOffset(i uint32) int64 {
+	return headerSize + int64(i)*h.slotSize()
+}
+
+// dataOffset returns the file offset of the data section.
+func (h *header) dataOffset() int64 {
+	return h.slotOffset(h.slots)
+}
+
+// homeSlot returns the slot a hash is stored in unless it is taken.
+func (h *header) homeSlot(hash []byte) uint32 {
+	sum := fnv.New64a()
+	sum.Write(hash)
+	return uint32(sum.Sum64() & uint64(h.slots-1)) //nolint:gosec
+}
+
+// parseHeader parses the header of a file and checks it against the file size.
+func parseHeader(data []byte, size int64) (*header, error) {
+	if len(data) < headerSize || size < headerSize+footerSize {
+		return nil, fmt.Errorf("%w: file too small", ErrInvalidFormat)
+	}
+	if string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: missing magic", ErrInvalidFormat)
+	}
+	if version := binary.LittleEndian.Uint16(data[4:]); version != Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+
+	h := &header{
+		hashLen:  int(binary.LittleEndian.Uint16(data[6:])),
+		count:    binary.LittleEndian.Uint32(data[8:]),
+		slots:    binary.LittleEndian.Uint32(data[12:]),
+		dataSize: binary.LittleEndian.Uint32(data[16:]),
+	}
+	if h.hashLen == 0 {
+		return nil, fmt.Errorf("%w: hash length is zero", ErrInvalidFormat)
+	}
+
+	// Lookups stop at the first empty slot, so there must always be one
+	if bits.OnesCount32(h.slots) != 1 || h.count >= h.slots {
+		return nil, fmt.Errorf("%w: %d slots for %d records", ErrInvalidFormat, h.slots, h.count)
+	}
+
+	// The sections must exactly fill the file
+	if expected := h.dataOffset() + int64(h.dataSize) + footerSize; size != expected {
+		return nil, fmt.Errorf("%w: file size %d does not match header, expected %d", ErrInvalidFormat, size, expected)
+	}
+
+	return h, nil
+}
+
+// slot is a parsed slot. Its hash points into the slot data.
+type slot struct {
+	hash       []byte
+	dataOffset uint32
+	dataLength uint32
+	confidence float64
+}
+
+// parseSlot parses a slot from its data.
+func (h *header) parseSlot(data []byte) slot {
+	fields := data[h.hashLen:]
+	return slot{
+		hash:       data[:h.hashLen],
+		dataOffset: binary.LittleEndian.Uint32(fields),
+		dataLength: binary.LittleEndian.Uint32(fields[4:]),
+		confidence: math.Float64frombits(binary.LittleEndian.Uint64(fields[8:])),
+	}
+}
+
+// empty reports whether the slot holds no record.
+func (s *slot) empty() bool {
+	return s.dataOffset == emptySlot
+}
+
+// parseData parses the status and reason of a slot from its data.
+func parseData(data []byte) (string, string, error) {
+	status, rest, err := parseString(data, "status")
+	if err != nil {
+		return "", "", err
+	}
+	reason, rest, err := parseString(rest, "reason")
+	if err != nil {
+		return "", "", err
+	}
+	if len(rest) != 0 {
+		return "", "", fmt.Errorf("%w: %d unexpected bytes after reason", ErrInvalidFormat, len(rest))
+	}
+	return status, reason, nil
+}
+
+// parseString parses a length-prefixed string, returning the data after it.
+func parseString(data []byte, fieldName string) (string, []byte, error) {
+	if len(data) < 2 {
+		return "", nil, fmt.Errorf("%w: %s length runs past the record data", ErrInvalidFormat, fieldName)
+	}
+	length := int(binary.LittleEndian.Uint16(data))
+	if len(data) < 2+length {
+		return "", nil, fmt.Errorf("%w: %s runs past the record data", ErrInvalidFormat, fieldName)
+	}
+	return string(data[2 : 2+length]), data[2+length:], nil
+}
+
+// result resolves the record of a slot, using readData to read its status and reason.
+func (h *header) result(s *slot, readData func(offset, length uint32) ([]byte, error)) (*common.CheckResult, error) {
+	if uint64(s.dataOffset)+uint64(s.dataLength) > uint64(h.dataSize) {
+		return nil, fmt.Errorf("%w: record data at %d runs past the data section", ErrInvalidFormat, s.dataOffset)
+	}
+
+	data, err := readData(s.dataOffset, s.dataLength)
+	if err != nil {
+		return nil, err
+	}
+	status, reason, err := parseData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.CheckResult{
+		Found:      true,
+		Status:     status,
+		Reason:     reason,
+		Confidence: s.confidence,
+	}, nil
+}
+
+// exportFile is an open constant database file for a single check type.
+type exportFile struct {
+	file   *os.File
+	header *header
+	err    error // Set if the file could not be opened
+}
+
+// openFile opens a constant database file and validates its header. The checksum is not verified,
+// since that would read the whole file; verification reports files that do not match it.
+func openFile(path string) *exportFile {
+	f := &exportFile{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		f.err = fmt.Errorf("failed to open file: %w", err)
+		return f
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		f.err = fmt.Errorf("failed to get file stats: %w", err)
+		return f
+	}
+
+	data := make([]byte, headerSize)
+	n, err := file.ReadAt(data, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		file.Close()
+		f.err = fmt.Errorf("failed to read header: %w", err)
+		return f
+	}
+	if f.header, err = parseHeader(data[:n], stat.Size()); err != nil {
+		file.Close()
+		f.err = err
+		return f
+	}
+
+	f.file = file
+	return f
+}
+
+// lookup finds the hash by probing the slots from its home slot, reading probeWindow slots at a time.
+func (f *exportFile) lookup(hash []byte) (*common.CheckResult, error) {
+	h := f.header
+	if len(hash) != h.hashLen {
+		return nil, fmt.Errorf("invalid hash format: %d bytes, expected %d", len(hash), h.hashLen)
+	}
+
+	buf := make([]byte, probeWindow*h.slotSize())
+	i := h.homeSlot(hash)
+	for probed := uint32(0); probed < h.slots; {
+		// Read up to the end of the slots, wrapping around on the next read
+		n := min(probeWindow, h.slots-i, h.slots-probed)
+		window := buf[:int64(n)*h.slotSize()]
+		if _, err := f.file.ReadAt(window, h.slotOffset(i)); err != nil {
+			return nil, fmt.Errorf("failed to read slots: %w", err)
+		}
+
+		for j := range n {
+			s := h.parseSlot(window[int64(j)*h.slotSize():])
+			if s.empty() {
+				return &common.CheckResult{}, nil
+			}
+			if bytes.Equal(s.hash, hash) {
+				return h.result(&s, f.readData)
+			}
+		}
+
+		probed += n
+		i = (i + n) & (h.slots - 1)
+	}
+
+	return &common.CheckResult{}, nil
+}
+
+// readData reads record data from the data section.
+func (f *exportFile) readData(offset, length uint32) ([]byte, error) {
+	data := make([]byte, length)
+	if _, err := f.file.ReadAt(data, f.header.dataOffset()+int64(offset)); err != nil {
+		return nil, fmt.Errorf("failed to read record data: %w", err)
+	}
+	return data, nil
+}
+
+// Checker implements the common.Checker interface for constant database storage.
+// Lookups read the files directly and can run concurrently.
+type Checker struct {
+	dir    string
+	mu     sync.RWMutex
+	users  *exportFile
+	groups *exportFile
+}
+
+// New creates a new constant database checker and opens the user and group files.
+// A file that cannot be opened returns its error on every lookup.
+func New(dir string) *Checker {
+	return &Checker{
+		dir:    dir,
+		users:  openFile(filepath.Join(dir, Filename(common.CheckTypeUser))),
+		groups: openFile(filepath.Join(dir, Filename(common.CheckTypeGroup))),
+	}
+}
+
+// exportFile returns the open file for the check type. The caller must hold the read lock.
+func (c *Checker) exportFile(checkType common.CheckType) (*exportFile, error) {
+	f := c.users
+	if checkType == common.CheckTypeGroup {
+		f = c.groups
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.file == nil {
+		return nil, common.ErrCheckerClosed
+	}
+	return f, nil
+}
+
+// Check verifies if the given hash exists in the constant database file.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash format: %w", err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return nil, err
+	}
+	return f.lookup(hash)
+}
+
+// CheckMany looks up each of the given hashes in the constant database file.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	results := make([]*common.CheckResult, len(hashes))
+	for i, id := range hashes {
+		result, err := c.Check(ctx, checkType, id)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// GetHashCount returns the number of records in the header of the constant database file.
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(f.header.count), nil
+}
+
+// Each calls fn with every record in the constant database file in slot order, stopping at the first error.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return err
+	}
+	h := f.header
+
+	// The data section only holds distinct statuses and reasons, so it is read once
+	data := make([]byte, h.dataSize)
+	if _, err := f.file.ReadAt(data, h.dataOffset()); err != nil {
+		return fmt.Errorf("failed to read record data: %w", err)
+	}
+	readData := func(offset, length uint32) ([]byte, error) {
+		return data[offset : offset+length], nil
+	}
+
+	slots := bufio.NewReader(io.NewSectionReader(f.file, headerSize, h.dataOffset()-headerSize))
+	buf := make([]byte, h.slotSize())
+	for i := range h.slots {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if _, err := io.ReadFull(slots, buf); err != nil {
+			return fmt.Errorf("failed to read slots: %w", err)
+		}
+		s := h.parseSlot(buf)
+		if s.empty() {
+			continue
+		}
+
+		result, err := h.result(&s, readData)
+		if err != nil {
+			return fmt.Errorf("slot %d: %w", i, err)
+		}
+		if err := fn(hex.EncodeToString(s.hash), result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the files. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, f := range []*exportFile{c.users, c.groups} {
+		if f.file == nil {
+			continue
+		}
+		if err := f.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file: %w", err))
+		}
+		f.file = nil
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,328 @@
+package cdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRecords returns n records with hashes of hashLen bytes.
+func testRecords(n, hashLen int) []Record {
+	records := make([]Record, n)
+	for i := range records {
+		records[i] = Record{
+			Hash:       fmt.Sprintf("%0*x", hashLen*2, i+1),
+			Status:     []string{"banned", "flagged"}[i%2],
+			Reason:     fmt.Sprintf("reason %d", i%3),
+			Confidence: float64(i%10) / 10,
+		}
+	}
+	return records
+}
+
+func setupTestFiles(t *testing.T, dir string, hashLen int, users, groups []Record) {
+	t.Helper()
+	_, err := Save(dir, common.CheckTypeUser, hashLen, users)
+	require.NoError(t, err)
+	_, err = Save(dir, common.CheckTypeGroup, hashLen, groups)
+	require.NoError(t, err)
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashLen int
+		count   int
+	}{
+		{name: "Empty file", hashLen: 32, count: 0},
+		{name: "Single record", hashLen: 32, count: 1},
+		{name: "Many records", hashLen: 32, count: 5000},
+		{name: "Short hashes that collide", hashLen: 1, count: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			records := testRecords(tt.count, tt.hashLen)
+			setupTestFiles(t, dir, tt.hashLen, records, nil)
+			checker := New(dir)
+			defer checker.Close()
+
+			for _, record := range records {
+				result, err := checker.Check(context.Background(), common.CheckTypeUser, record.Hash)
+				require.NoError(t, err)
+				assert.Equal(t, &common.CheckResult{
+					Found:      true,
+					Status:     record.Status,
+					Reason:     record.Reason,
+					Confidence: record.Confidence,
+				}, result, record.Hash)
+			}
+
+			// Hashes that are not in the file are not found
+			for _, hash := range []string{strings.Repeat("ff", tt.hashLen), strings.Repeat("00", tt.hashLen)} {
+				result, err := checker.Check(context.Background(), common.CheckTypeUser, hash)
+				require.NoError(t, err)
+				assert.False(t, result.Found, hash)
+			}
+
+			count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(tt.count), count)
+		})
+	}
+}
+
+func TestChecker_CheckMany(t *testing.T) {
+	dir := t.TempDir()
+	records := testRecords(10, 32)
+	setupTestFiles(t, dir, 32, records[:5], records[5:])
+	checker := New(dir)
+	defer checker.Close()
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeGroup,
+		[]string{records[6].Hash, records[0].Hash, records[9].Hash})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, records[6].Reason, results[0].Reason)
+	assert.False(t, results[1].Found)
+	assert.Equal(t, records[9].Status, results[2].Status)
+
+	// Invalid hashes are rejected
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "not hex")
+	require.Error(t, err)
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "abcd")
+	require.ErrorContains(t, err, "invalid hash format")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = checker.CheckMany(ctx, common.CheckTypeUser, []string{records[0].Hash})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestChecker_Each(t *testing.T) {
+	dir := t.TempDir()
+	records := testRecords(100, 32)
+	setupTestFiles(t, dir, 32, records, nil)
+	checker := New(dir)
+	defer checker.Close()
+
+	got := make(map[string]*common.CheckResult)
+	err := checker.Each(context.Background(), common.CheckTypeUser, func(hash string, result *common.CheckResult) error {
+		got[hash] = result
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, len(records))
+	for _, record := range records {
+		assert.Equal(t, record.Reason, got[record.Hash].Reason)
+	}
+
+	// Errors from fn stop the enumeration
+	errStop := fmt.Errorf("stop")
+	calls := 0
+	err = checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWrite_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashLen int
+		records []Record
+		wantErr string
+	}{
+		{
+			name:    "Wrong hash length",
+			hashLen: 2,
+			records: []Record{{Hash: "abcdef"}},
+			wantErr: "has 3 bytes, expected 2",
+		},
+		{
+			name:    "Hash that is not hex",
+			hashLen: 2,
+			records: []Record{{Hash: "zzzz"}},
+			wantErr: "invalid hash format",
+		},
+		{
+			name:    "Invalid hash length",
+			hashLen: 0,
+			wantErr: "invalid hash length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Write(&bytes.Buffer{}, tt.hashLen, tt.records)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// A failed save leaves no file behind
+	dir := t.TempDir()
+	_, err := Save(dir, common.CheckTypeUser, 2, []Record{{Hash: "zzzz"}})
+	require.Error(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSave_DuplicateHash(t *testing.T) {
+	dir := t.TempDir()
+	records := []Record{
+		{Hash: "abcd", Status: "banned", Reason: "first", Confidence: 0.9},
+		{Hash: "1234", Status: "flagged", Reason: "other", Confidence: 0.5},
+		{Hash: "ABCD", Status: "flagged", Reason: "second", Confidence: 0.1},
+	}
+	written, err := Save(dir, common.CheckTypeUser, 2, records)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	// The first record of the hash is kept, like the other storage formats return it
+	checker := New(dir)
+	defer checker.Close()
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "abcd")
+	require.NoError(t, err)
+	assert.Equal(t, &common.CheckResult{Found: true, Status: "banned", Reason: "first", Confidence: 0.9}, result)
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	problems, err := Walk(dir, common.CheckTypeUser, func(string, *common.CheckResult) error { return nil })
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestChecker_InvalidFile(t *testing.T) {
+	var valid bytes.Buffer
+	_, err := Write(&valid, 32, testRecords(3, 32))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "Too small",
+			data:    []byte("RTCD"),
+			wantErr: "file too small",
+		},
+		{
+			name:    "Missing magic",
+			data:    append([]byte("XXXX"), valid.Bytes()[4:]...),
+			wantErr: "missing magic",
+		},
+		{
+			name:    "Truncated",
+			data:    valid.Bytes()[:valid.Len()-1],
+			wantErr: "does not match header",
+		},
+		{
+			name: "Slot count not a power of two",
+			data: func() []byte {
+				data := bytes.Clone(valid.Bytes())
+				binary.LittleEndian.PutUint32(data[12:], 7)
+				return data
+			}(),
+			wantErr: "7 slots for 3 records",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "users.cdb"), tt.data, 0o600))
+			checker := New(dir)
+			defer checker.Close()
+
+			_, err := checker.Check(context.Background(), common.CheckTypeUser, strings.Repeat("00", 32))
+			require.ErrorIs(t, err, ErrInvalidFormat)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChecker_Close(t *testing.T) {
+	dir := t.TempDir()
+	setupTestFiles(t, dir, 32, testRecords(3, 32), nil)
+	checker := New(dir)
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close())
+
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, strings.Repeat("00", 32))
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
+
+func TestWalk(t *testing.T) {
+	records := testRecords(20, 32)
+
+	t.Run("Valid file", func(t *testing.T) {
+		dir := t.TempDir()
+		setupTestFiles(t, dir, 32, records, nil)
+
+		var hashes []string
+		problems, err := Walk(dir, common.CheckTypeUser, func(hash string, _ *common.CheckResult) error {
+			hashes = append(hashes, hash)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+		assert.Len(t, hashes, len(records))
+	})
+
+	t.Run("Corrupt file", func(t *testing.T) {
+		dir := t.TempDir()
+		setupTestFiles(t, dir, 32, records, nil)
+		path := filepath.Join(dir, "users.cdb")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		// Move the first record to a slot lookups never reach, which also breaks the checksum
+		h, err := parseHeader(data, int64(len(data)))
+		require.NoError(t, err)
+		var from, to uint32
+		for i := range h.slots {
+			s := h.parseSlot(data[h.slotOffset(i):])
+			next := h.parseSlot(data[h.slotOffset((i+1)&(h.slots-1)):])
+			nextNext := h.parseSlot(data[h.slotOffset((i+2)&(h.slots-1)):])
+			if !s.empty() && next.empty() && nextNext.empty() && h.homeSlot(s.hash) == i {
+				from, to = i, (i+2)&(h.slots-1)
+				break
+			}
+		}
+		size := h.slotSize()
+		copy(data[h.slotOffset(to):h.slotOffset(to)+size], data[h.slotOffset(from):h.slotOffset(from)+size])
+		copy(data[h.slotOffset(from):], emptySlotData(h.hashLen))
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		problems, err := Walk(dir, common.CheckTypeUser, func(string, *common.CheckResult) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, problems, 2)
+		require.ErrorIs(t, problems[0], ErrChecksumMismatch)
+		require.ErrorIs(t, problems[1], ErrUnreachableSlot)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Walk(t.TempDir(), common.CheckTypeGroup, nil)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
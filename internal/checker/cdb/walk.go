@@ -0,0 +1,107 @@
+package cdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+var (
+	ErrUnreachableSlot = errors.New("record cannot be reached from its home slot")
+	ErrDuplicateHash   = errors.New("duplicate hash")
+)
+
+// Walk fully parses the constant database file for the check type, calling fn with the hex hash of every
+// valid record. A checksum mismatch, records that lookups would not find, invalid field values and records
+// rejected by fn are returned as problems with the byte offset of their slot. An error is returned if the
+// file cannot be read at all, and fn returning common.ErrStopWalk ends the walk with that error.
+func Walk(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+	data, err := os.ReadFile(filepath.Join(dir, Filename(checkType)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	h, err := parseHeader(data, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	checksumOffset := len(data) - footerSize
+	sum, expected := crc32.ChecksumIEEE(data[:checksumOffset]), binary.LittleEndian.Uint32(data[checksumOffset:])
+	if sum != expected {
+		problems = append(problems, fmt.Errorf("offset %d: %w: got %08x, expected %08x",
+			checksumOffset, ErrChecksumMismatch, sum, expected))
+	}
+
+	section := data[h.dataOffset() : h.dataOffset()+int64(h.dataSize)]
+	readData := func(offset, length uint32) ([]byte, error) {
+		return section[offset : offset+length], nil
+	}
+
+	seen := make(map[string]struct{}, h.count)
+	var records uint32
+	for i := range h.slots {
+		offset := h.slotOffset(i)
+		s := h.parseSlot(data[offset : offset+h.slotSize()])
+		if s.empty() {
+			continue
+		}
+		records++
+
+		// Lookups stop at the first empty slot after the home slot
+		if !h.reachable(data, s.hash, i) {
+			problems = append(problems, fmt.Errorf("offset %d: %w: slot %d", offset, ErrUnreachableSlot, i))
+		}
+
+		hash := hex.EncodeToString(s.hash)
+		if _, ok := seen[hash]; ok {
+			problems = append(problems, fmt.Errorf("offset %d: %w: %s", offset, ErrDuplicateHash, hash))
+			continue
+		}
+		seen[hash] = struct{}{}
+
+		result, err := h.result(&s, readData)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: slot %d: %w", offset, i, err))
+			continue
+		}
+		if err := common.ValidateRecord(hash, result.Confidence); err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", offset, err))
+			continue
+		}
+
+		err = fn(hash, result)
+		if errors.Is(err, common.ErrStopWalk) {
+			return problems, err
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("offset %d: %w", offset, err))
+		}
+	}
+
+	if records != h.count {
+		problems = append(problems, fmt.Errorf("offset %d: %w: header has %d records but %d slots are used",
+			8, ErrInvalidFormat, h.count, records))
+	}
+
+	return problems, nil
+}
+
+// reachable reports whether probing for the hash from its home slot arrives at slot i before an empty slot.
+func (h *header) reachable(data []byte, hash []byte, i uint32) bool {
+	for j := h.homeSlot(hash); j != i; j = (j + 1) & (h.slots - 1) {
+		offset := h.slotOffset(j)
+		s := h.parseSlot(data[offset : offset+h.slotSize()])
+		if s.empty() || bytes.Equal(s.hash, hash) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package cdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// maxRecords is the largest number of records in a file, whose slot count must fit in a uint32.
+const maxRecords = 1 << 30
+
+// Record is a single record to write to a constant database file.
+type Record struct {
+	Hash       string // Hex-encoded
+	Status     string
+	Reason     string
+	Confidence float64
+}
+
+// Write writes the records to w in the constant database format, returning the number of records written.
+// All hashes must be hex-encoded hashes of hashLen bytes. Only the first record of a duplicated hash is
+// written, since it is the one the other storage formats return.
+func Write(w io.Writer, hashLen int, records []Record) (int, error) {
+	// Keep at least half the slots empty so probe sequences stay short
+	if hashLen <= 0 || hashLen > math.MaxUint16 || len(records) > maxRecords {
+		return 0, fmt.Errorf("%w: invalid hash length or too many records", ErrInvalidFormat)
+	}
+	h := &header{
+		hashLen: hashLen,
+		slots:   1,
+	}
+	for h.slots < 2*uint32(len(records)) { //nolint:gosec
+		h.slots <<= 1
+	}
+
+	// Place each record in the first free slot from its home slot, storing each distinct
+	// status and reason pair once in the data section
+	slots := bytes.Repeat(emptySlotData(hashLen), int(h.slots))
+	var data bytes.Buffer
+	offsets := make(map[[2]string]uint32)
+	for i := range records {
+		record := &records[i]
+		hash, err := hex.DecodeString(record.Hash)
+		if err != nil {
+			return 0, fmt.Errorf("invalid hash format: %w", err)
+		}
+		if len(hash) != hashLen {
+			return 0, fmt.Errorf("invalid hash format: %s has %d bytes, expected %d", record.Hash, len(hash), hashLen)
+		}
+		if len(record.Status) > math.MaxUint16 || len(record.Reason) > math.MaxUint16 {
+			return 0, fmt.Errorf("%w: status or reason of %s too long", ErrInvalidFormat, record.Hash)
+		}
+
+		// Probe for a free slot, skipping the record if its hash was already written
+		index := h.homeSlot(hash)
+		duplicate := false
+		for {
+			s := h.parseSlot(slots[int64(index)*h.slotSize():])
+			if s.empty() {
+				break
+			}
+			if bytes.Equal(s.hash, hash) {
+				duplicate = true
+				break
+			}
+			index = (index + 1) & (h.slots - 1)
+		}
+		if duplicate {
+			continue
+		}
+
+		key := [2]string{record.Status, record.Reason}
+		offset, ok := offsets[key]
+		if !ok {
+			if uint64(data.Len())+4+uint64(len(record.Status))+uint64(len(record.Reason)) >= emptySlot {
+				return 0, fmt.Errorf("%w: record data too large", ErrInvalidFormat)
+			}
+			offset = uint32(data.Len()) //nolint:gosec
+			_ = binary.Write(&data, binary.LittleEndian, uint16(len(record.Status)))
+			data.WriteString(record.Status)
+			_ = binary.Write(&data, binary.LittleEndian, uint16(len(record.Reason)))
+			data.WriteString(record.Reason)
+			offsets[key] = offset
+		}
+		length := uint32(4 + len(record.Status) + len(record.Reason)) //nolint:gosec
+
+		entry := slots[int64(index)*h.slotSize() : int64(index+1)*h.slotSize()]
+		copy(entry, hash)
+		binary.LittleEndian.PutUint32(entry[hashLen:], offset)
+		binary.LittleEndian.PutUint32(entry[hashLen+4:], length)
+		binary.LittleEndian.PutUint64(entry[hashLen+8:], math.Float64bits(record.Confidence))
+		h.count++
+	}
+	h.dataSize = uint32(data.Len()) //nolint:gosec
+
+	// Write the header, slots and data, then the checksum of all three
+	headerData := make([]byte, headerSize)
+	copy(headerData, magic)
+	binary.LittleEndian.PutUint16(headerData[4:], Version)
+	binary.LittleEndian.PutUint16(headerData[6:], uint16(hashLen)) //nolint:gosec
+	binary.LittleEndian.PutUint32(headerData[8:], h.count)
+	binary.LittleEndian.PutUint32(headerData[12:], h.slots)
+	binary.LittleEndian.PutUint32(headerData[16:], h.dataSize)
+
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(w, crc)
+	for _, section := range [][]byte{headerData, slots, data.Bytes()} {
+		if _, err := out.Write(section); err != nil {
+			return 0, fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+	if err := binary.Write(w, binary.LittleEndian, crc.Sum32()); err != nil {
+		return 0, fmt.Errorf("failed to write checksum: %w", err)
+	}
+
+	return int(h.count), nil
+}
+
+// emptySlotData returns the data of an empty slot.
+func emptySlotData(hashLen int) []byte {
+	data := make([]byte, hashLen+16)
+	binary.LittleEndian.PutUint32(data[hashLen:], emptySlot)
+	return data
+}
+
+// Save writes the records for the check type to the export directory, returning the number of records
+// written. The file is written under a temporary name first, so a failed write never leaves a partial
+// file behind.
+func Save(dir string, checkType common.CheckType, hashLen int, records []Record) (int, error) {
+	file, err := os.CreateTemp(dir, Filename(checkType)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	w := bufio.NewWriter(file)
+	written, err := Write(w, hashLen, records)
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), filepath.Join(dir, Filename(checkType))); err != nil {
+		return 0, fmt.Errorf("failed to save file: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,48 @@
+package checker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCDB(t *testing.T) {
+	dir := setupVerifyExport(t)
+
+	for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+		_, err := GenerateCDB(dir, checkType, common.StorageTypeBinary)
+		require.NoError(t, err)
+	}
+
+	// The new files hold the same records as the rest of the export
+	report := NewValidator().Verify(dir)
+	assert.True(t, report.OK())
+	require.Len(t, report.Files, 8)
+
+	checker, err := New(dir, common.StorageTypeCDB)
+	require.NoError(t, err)
+	defer checker.Close()
+
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, testHash(1))
+	require.NoError(t, err)
+	assert.Equal(t, &common.CheckResult{Found: true, Status: "confirmed", Reason: "a; b", Confidence: 0.9}, result)
+
+	result, err = checker.Check(context.Background(), common.CheckTypeGroup, testHash(1))
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+}
+
+func TestGenerateCDB_CorruptRecords(t *testing.T) {
+	dir := setupVerifyExport(t)
+	content := "hash,status,reason,confidence\n" + testHash(1) + ",flagged,c,high\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	_, err := GenerateCDB(dir, common.CheckTypeUser, common.StorageTypeCSV)
+	require.ErrorIs(t, err, ErrCorruptRecords)
+	assert.NoFileExists(t, filepath.Join(dir, "users.cdb"))
+}
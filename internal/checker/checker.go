@@ -1,33 +1,105 @@
 package checker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/robalyx/rotten/internal/checker/binary"
+	"github.com/robalyx/rotten/internal/checker/cdb"
 	"github.com/robalyx/rotten/internal/checker/csv"
+	"github.com/robalyx/rotten/internal/checker/indexed"
+	"github.com/robalyx/rotten/internal/checker/jsonl"
+	"github.com/robalyx/rotten/internal/checker/remote"
 	"github.com/robalyx/rotten/internal/checker/sqlite"
 	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/hasher"
 )
 
 var ErrUnsupportedStorageType = errors.New("unsupported storage type")
 
 // Checker interface defines the methods required for checking IDs.
 type Checker interface {
-	Check(checkType common.CheckType, id string) (*common.CheckResult, error)
-	GetHashCount(checkType common.CheckType) (uint64, error)
+	Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error)
+	CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error)
+	GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error)
+
+	// Each calls fn with every record of the check type, stopping at the first error, which it returns.
+	// Checkers may hold a lock while calling fn, so fn must not use the checker.
+	Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error
+
+	Close() error
+}
+
+// IndexedChecker is implemented by checkers that load the export into memory.
+type IndexedChecker interface {
+	Checker
+	LoadStats(ctx context.Context, checkType common.CheckType) (indexed.LoadStats, error)
+}
+
+// Options configures how a checker opens an export.
+type Options struct {
+	// Strict refuses exports that would slow every lookup down, such as SQLite databases
+	// without an index on the hash column, instead of reporting them as warnings.
+	Strict bool
+
+	// Remote configures the server called by the remote storage type, which ignores the export directory.
+	Remote remote.Config
 }
 
 // New creates a new checker instance based on the storage type.
+// The checker keeps its storage files open until Close is called. If the export has bloom filters,
+// the file-based checkers consult them first to skip lookups of hashes that are not in the export.
 func New(dir string, storageType common.StorageType) (Checker, error) {
+	return NewWithOptions(dir, storageType, Options{})
+}
+
+// NewWithOptions creates a new checker instance based on the storage type using opts.
+func NewWithOptions(dir string, storageType common.StorageType, opts Options) (Checker, error) {
+	var c Checker
 	switch storageType {
 	case common.StorageTypeSQLite:
-		return sqlite.New(dir), nil
+		c = sqlite.NewWithOptions(dir, sqlite.Options{Strict: opts.Strict})
 	case common.StorageTypeBinary:
-		return binary.New(dir), nil
+		c = binary.New(dir)
 	case common.StorageTypeCSV:
-		return csv.New(dir), nil
+		c = csv.New(dir)
+	case common.StorageTypeJSONL:
+		c = jsonl.New(dir)
+	case common.StorageTypeCDB:
+		c = cdb.New(dir)
+	case common.StorageTypeCSVIndexed:
+		// Indexed checkers answer every lookup from memory, so a filter would not save anything
+		return indexed.New(dir, csv.Walk), nil
+	case common.StorageTypeBinaryIndexed:
+		return indexed.New(dir, func(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+			return binary.Walk(dir, checkType, hasher.Size, fn)
+		}), nil
+	case common.StorageTypeRemote:
+		// The server answers lookups of hashes it does not have, so there are no local filters to consult
+		return remote.New(opts.Remote)
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedStorageType, storageType)
 	}
+
+	filtered, err := withBloomFilters(dir, storageType, c)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// Warnings returns the problems the checker found in the export that slow down lookups without
+// preventing them.
+func Warnings(c Checker) []error {
+	var warnings []error
+	if f, ok := c.(*filteredChecker); ok {
+		warnings = append(warnings, f.Warnings()...)
+		c = f.Checker
+	}
+	if w, ok := c.(interface{ Warnings() []error }); ok {
+		warnings = append(warnings, w.Warnings()...)
+	}
+	return warnings
 }
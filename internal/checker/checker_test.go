@@ -1,10 +1,15 @@
 package checker
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/robalyx/rotten/internal/checker/remote"
 	"github.com/robalyx/rotten/internal/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -87,6 +92,16 @@ func TestNew(t *testing.T) {
 			storageType: common.StorageTypeCSV,
 			wantError:   false,
 		},
+		{
+			name:        "Indexed CSV storage",
+			storageType: common.StorageTypeCSVIndexed,
+			wantError:   false,
+		},
+		{
+			name:        "Indexed binary storage",
+			storageType: common.StorageTypeBinaryIndexed,
+			wantError:   false,
+		},
 		{
 			name:        "Invalid storage type",
 			storageType: "invalid",
@@ -109,6 +124,26 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithOptions_Remote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/check/user", r.URL.Path)
+		_, _ = w.Write([]byte(`{"results": [{"found": true, "status": "banned", "reason": "violation", "confidence": 0.9}]}`))
+	}))
+	defer server.Close()
+
+	// The export directory is not used by remote checkers
+	checker, err := NewWithOptions("", common.StorageTypeRemote, Options{Remote: remote.Config{BaseURL: server.URL}})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, &common.CheckResult{Found: true, Status: "banned", Reason: "violation", Confidence: 0.9}, result)
+
+	_, err = NewWithOptions("", common.StorageTypeRemote, Options{})
+	require.ErrorIs(t, err, remote.ErrInvalidConfig)
+}
+
 func TestChecker_Integration(t *testing.T) {
 	tempDir := t.TempDir()
 	setupTestFiles(t, tempDir)
@@ -150,6 +185,16 @@ func TestChecker_Integration(t *testing.T) {
 			storageType: common.StorageTypeCSV,
 			checkType:   common.CheckTypeGroup,
 		},
+		{
+			name:        "Indexed CSV user check",
+			storageType: common.StorageTypeCSVIndexed,
+			checkType:   common.CheckTypeUser,
+		},
+		{
+			name:        "Indexed binary group check",
+			storageType: common.StorageTypeBinaryIndexed,
+			checkType:   common.CheckTypeGroup,
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,12 +204,12 @@ func TestChecker_Integration(t *testing.T) {
 			require.NotNil(t, checker)
 
 			// Test GetHashCount
-			count, err := checker.GetHashCount(tt.checkType)
+			count, err := checker.GetHashCount(context.Background(), tt.checkType)
 			assert.NoError(t, err)
 			assert.Zero(t, count) // Empty test files should have 0 hashes
 
 			// Test Check with valid but non-existent hash
-			result, err := checker.Check(tt.checkType, testHash)
+			result, err := checker.Check(context.Background(), tt.checkType, testHash)
 			assert.NoError(t, err)
 			assert.False(t, result.Found)
 			assert.Empty(t, result.Status)
@@ -193,6 +238,10 @@ func TestChecker_InvalidDirectory(t *testing.T) {
 			name:        "CSV storage",
 			storageType: common.StorageTypeCSV,
 		},
+		{
+			name:        "Indexed CSV storage",
+			storageType: common.StorageTypeCSVIndexed,
+		},
 	}
 
 	for _, tt := range tests {
@@ -202,13 +251,96 @@ func TestChecker_InvalidDirectory(t *testing.T) {
 			require.NotNil(t, checker)
 
 			// GetHashCount should fail
-			_, err = checker.GetHashCount(common.CheckTypeUser)
+			_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
 			assert.Error(t, err)
 
 			// Check should fail
-			result, err := checker.Check(common.CheckTypeUser, testHash)
+			result, err := checker.Check(context.Background(), common.CheckTypeUser, testHash)
 			assert.Error(t, err)
 			assert.Nil(t, result)
 		})
 	}
 }
+
+func TestChecker_CancelledContext(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	testHash := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, storageType := range []common.StorageType{
+		common.StorageTypeSQLite, common.StorageTypeBinary, common.StorageTypeCSV,
+	} {
+		t.Run(string(storageType), func(t *testing.T) {
+			checker, err := New(tempDir, storageType)
+			require.NoError(t, err)
+
+			_, err = checker.Check(ctx, common.CheckTypeUser, testHash)
+			assert.ErrorIs(t, err, context.Canceled)
+
+			_, err = checker.CheckMany(ctx, common.CheckTypeUser, []string{testHash})
+			assert.ErrorIs(t, err, context.Canceled)
+
+			_, err = checker.GetHashCount(ctx, common.CheckTypeUser)
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
+
+func TestChecker_Each(t *testing.T) {
+	dir := setupVerifyExport(t)
+	want := map[string]common.CheckResult{
+		testHash(1): {Found: true, Status: "confirmed", Reason: "a; b", Confidence: 0.9},
+		testHash(2): {Found: true, Status: "flagged", Reason: "c", Confidence: 0.5},
+	}
+	users := `{"hash":"` + testHash(1) + `","status":"confirmed","reason":["a","b"],"confidence":0.9}` + "\n" +
+		`{"hash":"` + testHash(2) + `","status":"flagged","reason":"c","confidence":0.5}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.jsonl"), []byte(users), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "groups.jsonl"), nil, 0o600))
+	for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+		_, err := GenerateCDB(dir, checkType, common.StorageTypeCSV)
+		require.NoError(t, err)
+	}
+
+	// Every storage type enumerates the same records
+	storageTypes := []common.StorageType{
+		common.StorageTypeSQLite, common.StorageTypeBinary, common.StorageTypeCSV, common.StorageTypeJSONL,
+		common.StorageTypeCDB, common.StorageTypeCSVIndexed, common.StorageTypeBinaryIndexed,
+	}
+	for _, storageType := range storageTypes {
+		t.Run(string(storageType), func(t *testing.T) {
+			checker, err := New(dir, storageType)
+			require.NoError(t, err)
+			defer checker.Close()
+
+			got := make(map[string]common.CheckResult)
+			err = checker.Each(context.Background(), common.CheckTypeFriends, func(hash string, result *common.CheckResult) error {
+				got[hash] = *result
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			// An error from fn stops the enumeration
+			errStop := errors.New("stop")
+			calls := 0
+			err = checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error {
+				calls++
+				return errStop
+			})
+			assert.Equal(t, errStop, err)
+			assert.Equal(t, 1, calls)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err = checker.Each(ctx, common.CheckTypeGroup, func(string, *common.CheckResult) error { return nil })
+			assert.ErrorIs(t, err, context.Canceled)
+
+			require.NoError(t, checker.Close())
+			err = checker.Each(context.Background(), common.CheckTypeGroup, func(string, *common.CheckResult) error { return nil })
+			assert.ErrorIs(t, err, common.ErrCheckerClosed)
+		})
+	}
+}
@@ -1,21 +1,40 @@
 package csv
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 
 	"github.com/robalyx/rotten/internal/common"
 )
 
+// ctxCheckInterval is the number of records read between context checks when reading whole files.
+const ctxCheckInterval = 1024
+
 var ErrInvalidFormat = errors.New("invalid CSV format")
 
+// exportFile is an open CSV file for a single check type.
+type exportFile struct {
+	file       *os.File
+	size       int64
+	compressed bool  // Set for gzip-compressed files
+	err        error // Set if the file could not be opened
+}
+
 // Checker implements the common.Checker interface for CSV storage.
+// The files are opened once and each lookup reads through its own section reader.
 type Checker struct {
-	dir string
+	dir    string
+	mu     sync.RWMutex
+	users  *exportFile
+	groups *exportFile
 }
 
 // Result contains the check result details.
@@ -25,113 +44,337 @@ type Result struct {
 	Reason string
 }
 
-// New creates a new CSV checker.
+// New creates a new CSV checker and opens the user and group files.
+// A file that cannot be opened returns its error on every lookup.
 func New(dir string) *Checker {
-	return &Checker{dir: dir}
+	return &Checker{
+		dir:    dir,
+		users:  openFile(findFile(dir, common.CheckTypeUser)),
+		groups: openFile(findFile(dir, common.CheckTypeGroup)),
+	}
 }
 
-// Check verifies if the given ID exists in the CSV file.
-func (c *Checker) Check(checkType common.CheckType, id string) (*common.CheckResult, error) {
-	// Determine filename based on check type
-	filename := "users.csv"
+// Filenames returns the names the CSV file for the check type may have, uncompressed first.
+func Filenames(checkType common.CheckType) []string {
 	if checkType == common.CheckTypeGroup {
-		filename = "groups.csv"
+		return []string{"groups.csv", "groups.csv.gz"}
+	}
+	return []string{"users.csv", "users.csv.gz"}
+}
+
+// findFile returns the path of the CSV file for the check type, preferring the uncompressed file.
+// If neither file exists, the path of the uncompressed file is returned.
+func findFile(dir string, checkType common.CheckType) string {
+	filenames := Filenames(checkType)
+	for _, filename := range filenames {
+		path := filepath.Join(dir, filename)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, filenames[0])
+}
+
+// openFile opens a CSV file and validates its header.
+func openFile(path string) *exportFile {
+	f := &exportFile{compressed: filepath.Ext(path) == ".gz"}
+
+	file, err := os.Open(path)
+	if err != nil {
+		f.err = fmt.Errorf("failed to open file: %w", err)
+		return f
 	}
 
-	// Open file
-	file, err := os.Open(filepath.Join(c.dir, filename))
+	stat, err := file.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		file.Close()
+		f.err = fmt.Errorf("failed to get file stats: %w", err)
+		return f
 	}
-	defer file.Close()
 
-	// Create CSV reader
-	reader := csv.NewReader(file)
+	// Validate header
+	f.file, f.size = file, stat.Size()
+	if _, err := f.reader(); err != nil {
+		file.Close()
+		f.file, f.err = nil, err
+		return f
+	}
+
+	return f
+}
+
+// reader returns a CSV reader positioned after the header, decompressing the file if needed.
+func (f *exportFile) reader() (*csv.Reader, error) {
+	var r io.Reader = io.NewSectionReader(f.file, 0, f.size)
+	if f.compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decompress file: %w", ErrInvalidFormat, err)
+		}
+		r = gz
+	}
+
+	reader, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	reader.ReuseRecord = true
+	return reader, nil
+}
+
+// reader returns a CSV reader positioned after the header of the file for the check type.
+// The caller must hold the read lock.
+func (c *Checker) reader(checkType common.CheckType) (*csv.Reader, error) {
+	f := c.users
+	if checkType == common.CheckTypeGroup {
+		f = c.groups
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.file == nil {
+		return nil, common.ErrCheckerClosed
+	}
+
+	return f.reader()
+}
+
+// readHeader creates a CSV reader and reads and validates the header.
+func readHeader(r io.Reader) (*csv.Reader, error) {
+	// Allow records with any number of fields so that the lookups report them as ErrInvalidFormat
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
 
-	// Read header
 	header, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to read header", ErrInvalidFormat)
 	}
 
-	// Validate header
 	if err := validateHeader(header); err != nil {
 		return nil, err
 	}
 
-	// Read all records
-	records, err := reader.ReadAll()
+	return reader, nil
+}
+
+// Check verifies if the given ID exists in the CSV file.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader, err := c.reader(checkType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+		return nil, err
 	}
 
-	// Check each record
-	for _, record := range records {
+	// Read each record until the hash is found
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return &common.CheckResult{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
 		if len(record) != 4 {
-			return nil, fmt.Errorf("%w: incorrect number of columns", ErrInvalidFormat)
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w: incorrect number of columns", line, ErrInvalidFormat)
 		}
 		if record[0] == id {
-			confidence, err := strconv.ParseFloat(record[3], 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid confidence value: %w", err)
-			}
+			return parseResult(record)
+		}
+	}
+}
 
-			result := common.CheckResult{
-				Found:      true,
-				Status:     record[1],
-				Reason:     record[2],
-				Confidence: confidence,
-			}
-			return &result, nil
+// CheckMany verifies which of the given hashes exist in the CSV file using a single pass.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Map each hash to its positions in the results
+	results := make([]*common.CheckResult, len(hashes))
+	positions := make(map[string][]int, len(hashes))
+	for i, hash := range hashes {
+		results[i] = &common.CheckResult{}
+		positions[hash] = append(positions[hash], i)
+	}
+	if len(hashes) == 0 {
+		return results, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader, err := c.reader(checkType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read each record until every hash has been found
+	for len(positions) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(record) != 4 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: %w: incorrect number of columns", line, ErrInvalidFormat)
+		}
+
+		indices, ok := positions[record[0]]
+		if !ok {
+			continue
+		}
+
+		result, err := parseResult(record)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indices {
+			results[i] = result
 		}
+		delete(positions, record[0])
 	}
 
-	return &common.CheckResult{}, nil
+	return results, nil
 }
 
 // GetHashCount returns the number of hashes in the CSV file.
-func (c *Checker) GetHashCount(checkType common.CheckType) (uint64, error) {
-	// Determine filename based on check type
-	filename := "users.csv"
-	if checkType == common.CheckTypeGroup {
-		filename = "groups.csv"
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
 
-	// Open file
-	file, err := os.Open(filepath.Join(c.dir, filename))
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader, err := c.reader(checkType)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open file: %w", err)
+		return 0, err
 	}
-	defer file.Close()
 
-	// Create CSV reader
-	reader := csv.NewReader(file)
+	// Count the records, validating their format
+	var count uint64
+	for {
+		if count%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
 
-	// Read header
-	header, err := reader.Read()
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(record) != 4 {
+			line, _ := reader.FieldPos(0)
+			return 0, fmt.Errorf("line %d: %w: incorrect number of columns", line, ErrInvalidFormat)
+		}
+		count++
+	}
+}
+
+// Each calls fn with every record in the CSV file, stopping at the first error.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader, err := c.reader(checkType)
 	if err != nil {
-		return 0, fmt.Errorf("%w: failed to read header", ErrInvalidFormat)
+		return err
 	}
 
-	// Validate header
-	if err := validateHeader(header); err != nil {
-		return 0, err
+	for i := 0; ; i++ {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(record) != 4 {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w: incorrect number of columns", line, ErrInvalidFormat)
+		}
+
+		result, err := parseResult(record)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(record[0], result); err != nil {
+			return err
+		}
 	}
+}
 
-	// Read all records
-	records, err := reader.ReadAll()
+// parseResult converts a found record into a check result.
+func parseResult(record []string) (*common.CheckResult, error) {
+	confidence, err := strconv.ParseFloat(record[3], 64)
 	if err != nil {
-		return 0, fmt.Errorf("failed to read CSV: %w", err)
+		return nil, fmt.Errorf("invalid confidence value: %w", err)
 	}
 
-	// Validate record format
-	for _, record := range records {
-		if len(record) != 4 {
-			return 0, fmt.Errorf("%w: incorrect number of columns", ErrInvalidFormat)
+	return &common.CheckResult{
+		Found:      true,
+		Status:     record[1],
+		Reason:     record[2],
+		Confidence: confidence,
+	}, nil
+}
+
+// Err returns the error that prevents lookups in the file for the check type, or nil if it opened.
+func (c *Checker) Err(checkType common.CheckType) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, err := c.reader(checkType)
+	return err
+}
+
+// Close closes the files. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, f := range []*exportFile{c.users, c.groups} {
+		if f.file == nil {
+			continue
+		}
+		if err := f.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file: %w", err))
 		}
+		f.file = nil
 	}
 
-	return uint64(len(records)), nil
+	return errors.Join(errs...)
 }
 
 // validateHeader checks if the CSV file has the correct header format.
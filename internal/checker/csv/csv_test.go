@@ -1,8 +1,12 @@
 package csv
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/robalyx/rotten/internal/common"
@@ -85,7 +89,7 @@ func TestChecker_Check(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			result, err := checker.Check(tt.checkType, tt.hash)
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -136,7 +140,7 @@ func TestChecker_GetHashCount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			count, err := checker.GetHashCount(tt.checkType)
+			count, err := checker.GetHashCount(context.Background(), tt.checkType)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -154,12 +158,12 @@ func TestChecker_NonexistentFile(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with nonexistent file
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with nonexistent file
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -172,14 +176,15 @@ func TestChecker_InvalidFileFormat(t *testing.T) {
 	require.NoError(t, err)
 
 	checker := New(tempDir)
+	require.ErrorIs(t, checker.Err(common.CheckTypeUser), ErrInvalidFormat)
 
 	// Test Check with invalid file format
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with invalid file format
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -195,12 +200,12 @@ func TestChecker_MalformedCSV(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with malformed CSV
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with malformed CSV
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -216,9 +221,20 @@ func TestChecker_IncorrectColumnCount(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with incorrect column count
-	result, err := checker.Check(common.CheckTypeUser, "testHash123")
-	assert.Error(t, err)
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.ErrorIs(t, err, ErrInvalidFormat)
+	assert.Contains(t, err.Error(), "line 2")
 	assert.Nil(t, result)
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"testHash123"})
+	require.ErrorIs(t, err, ErrInvalidFormat)
+	assert.Nil(t, results)
+
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.ErrorIs(t, err, ErrInvalidFormat)
+
+	err = checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error { return nil })
+	require.ErrorIs(t, err, ErrInvalidFormat)
 }
 
 func TestChecker_EmptyFile(t *testing.T) {
@@ -231,12 +247,152 @@ func TestChecker_EmptyFile(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with empty file
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with empty file
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	assert.Error(t, err)
+	assert.Zero(t, count)
+}
+
+func TestChecker_CheckMany(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+	checker := New(tempDir)
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"0123456789abcdef", "testHash123", "testHash123"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Found)
+	for _, result := range results[1:] {
+		assert.True(t, result.Found)
+		assert.Equal(t, "banned", result.Status)
+		assert.Equal(t, "violation", result.Reason)
+		assert.InDelta(t, 0.95, result.Confidence, 0.001)
+	}
+
+	// No hashes does not touch the file
+	results, err = New(t.TempDir()).CheckMany(context.Background(), common.CheckTypeUser, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = New(t.TempDir()).CheckMany(context.Background(), common.CheckTypeUser, []string{"testHash123"})
 	assert.Error(t, err)
+}
+
+func TestChecker_Close(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	checker := New(tempDir)
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.NoError(t, err)
+
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close(), "Close should be idempotent")
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.CheckMany(context.Background(), common.CheckTypeGroup, []string{"testHash123"})
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeGroup)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
+
+func TestChecker_Compressed(t *testing.T) {
+	tempDir := t.TempDir()
+	hash1, hash2 := strings.Repeat("ab", 32), strings.Repeat("cd", 32)
+	content := "hash,status,reason,confidence\n" + hash1 + ",banned,violation,0.95\n" + hash2 + ",flagged,spam,0.5\n"
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.csv.gz"), buf.Bytes(), 0o600))
+
+	checker := New(tempDir)
+	defer checker.Close()
+
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, hash2)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "spam", result.Reason)
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{hash1, "missing"})
+	require.NoError(t, err)
+	assert.True(t, results[0].Found)
+	assert.False(t, results[1].Found)
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	var hashes []string
+	problems, err := Walk(tempDir, common.CheckTypeUser, func(hash string, _ *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, []string{hash1, hash2}, hashes)
+
+	// Returning ErrStopWalk ends the walk instead of reporting a problem for each record
+	calls := 0
+	problems, err = Walk(tempDir, common.CheckTypeUser, func(string, *common.CheckResult) error {
+		calls++
+		return common.ErrStopWalk
+	})
+	require.ErrorIs(t, err, common.ErrStopWalk)
+	assert.Empty(t, problems)
+	assert.Equal(t, 1, calls)
+
+	// The uncompressed file is preferred when both exist
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.csv"), []byte("hash,status,reason,confidence\n"), 0o600))
+	count, err = New(tempDir).GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
 	assert.Zero(t, count)
+
+	// A file that is not gzip-compressed is rejected
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "groups.csv.gz"), []byte(content), 0o600))
+	_, err = New(tempDir).Check(context.Background(), common.CheckTypeGroup, hash1)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestChecker_CheckStopsAtMatch(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Records after the match are not read
+	content := "hash,status,reason,confidence\ntestHash123,banned,violation,0.95\n\"unclosed quote,bad,data,0.5\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.csv"), []byte(content), 0o600))
+
+	checker := New(tempDir)
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	assert.Error(t, err)
+}
+
+func TestChecker_Each(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "hash,status,reason,confidence\ntestHash123,banned,violation,0.95\notherHash456,flagged,spam,high\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.csv"), []byte(content), 0o600))
+
+	checker := New(tempDir)
+	defer checker.Close()
+
+	// Records are enumerated until the corrupt one
+	var hashes []string
+	err := checker.Each(context.Background(), common.CheckTypeUser, func(hash string, result *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		assert.Equal(t, "violation", result.Reason)
+		return nil
+	})
+	assert.Equal(t, []string{"testHash123"}, hashes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3: invalid confidence value")
 }
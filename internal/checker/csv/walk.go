@@ -0,0 +1,94 @@
+package csv
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Walk fully parses the CSV file for the check type, calling fn with the hash of every valid record.
+// Corrupt records, and records rejected by fn, are returned as problems with their line number, and
+// parsing continues past them.
+// An error is returned if the file cannot be read at all or fn stops the walk with common.ErrStopWalk.
+func Walk(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+	// Open file
+	path := findFile(dir, checkType)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if filepath.Ext(path) == ".gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decompress file: %w", ErrInvalidFormat, err)
+		}
+		r = gz
+	}
+
+	// Allow records with any number of fields so they can be reported individually
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	// Read and validate header
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header", ErrInvalidFormat)
+	}
+	if err := validateHeader(header); err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			problems = append(problems, fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return problems, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != 4 {
+			problems = append(problems, fmt.Errorf("line %d: %w: incorrect number of columns", line, ErrInvalidFormat))
+			continue
+		}
+
+		confidence, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("line %d: invalid confidence value: %w", line, err))
+			continue
+		}
+		if err := common.ValidateRecord(record[0], confidence); err != nil {
+			problems = append(problems, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+
+		result := &common.CheckResult{Found: true, Status: record[1], Reason: record[2], Confidence: confidence}
+		err = fn(record[0], result)
+		if errors.Is(err, common.ErrStopWalk) {
+			return problems, err
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("line %d: %w", line, err))
+		}
+	}
+
+	return problems, nil
+}
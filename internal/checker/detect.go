@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/checker/binary"
+	"github.com/robalyx/rotten/internal/checker/cdb"
+	"github.com/robalyx/rotten/internal/checker/csv"
+	"github.com/robalyx/rotten/internal/checker/jsonl"
+	"github.com/robalyx/rotten/internal/checker/sqlite"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+var ErrNoUsableStorage = errors.New("no usable storage files found")
+
+// Detection is the storage type chosen for an export by Detect.
+type Detection struct {
+	StorageType common.StorageType
+	Reason      string  // Why the storage type was chosen
+	Skipped     []error // Storage files that are present but could not be opened
+}
+
+// Detect inspects the storage files in the export directory for the check type and returns the storage
+// type that answers lookups fastest among those whose file opens. Friends checks use the user files.
+//
+// From fastest to slowest, the storage types are a constant database, an indexed SQLite database,
+// a sorted binary file, an unsorted binary or CSV file loaded into memory, an unindexed SQLite database
+// and a JSON Lines file. Only the headers of the files are read, except for sorted binary files, whose
+// checksum is verified when they are opened.
+func Detect(dir string, checkType common.CheckType) (*Detection, error) {
+	if checkType != common.CheckTypeGroup {
+		checkType = common.CheckTypeUser
+	}
+
+	v := NewValidator()
+	detection := &Detection{}
+	unindexedSQLite := false
+
+	// probe opens the file of the storage type, recording why it cannot be used.
+	// It reports false if the file is missing or cannot be opened.
+	probe := func(storageType common.StorageType, open func() error) bool {
+		filename, err := v.findFile(dir, checkType, storageType)
+		if err != nil {
+			return false
+		}
+		if err := open(); err != nil {
+			detection.Skipped = append(detection.Skipped, fmt.Errorf("%s: %w", filename, err))
+			return false
+		}
+		return true
+	}
+	choose := func(storageType common.StorageType, reason string) (*Detection, error) {
+		detection.StorageType, detection.Reason = storageType, reason
+		return detection, nil
+	}
+
+	// Constant databases find every hash in one or two reads
+	if probe(common.StorageTypeCDB, func() error {
+		c := cdb.New(dir)
+		defer c.Close()
+		_, err := c.GetHashCount(context.Background(), checkType)
+		return err
+	}) {
+		return choose(common.StorageTypeCDB, "constant database finds each hash in one or two reads")
+	}
+
+	// SQLite is only fast with an index on the hash column
+	if probe(common.StorageTypeSQLite, func() error {
+		c := sqlite.New(dir)
+		defer c.Close()
+		indexed, err := c.Indexed(checkType)
+		unindexedSQLite = err == nil && !indexed
+		return err
+	}) && !unindexedSQLite {
+		return choose(common.StorageTypeSQLite, "SQLite database has an index on the hash column")
+	}
+
+	// Sorted binary files are searched in place, unsorted ones are loaded into memory
+	var version int
+	if probe(common.StorageTypeBinary, func() error {
+		c := binary.New(dir)
+		defer c.Close()
+		var err error
+		version, err = c.Version(checkType)
+		return err
+	}) {
+		if version == binary.Version2 {
+			return choose(common.StorageTypeBinary, "sorted binary file is binary searched")
+		}
+		return choose(common.StorageTypeBinaryIndexed, "unsorted binary file is loaded into memory once for instant lookups")
+	}
+
+	if probe(common.StorageTypeCSV, func() error {
+		c := csv.New(dir)
+		defer c.Close()
+		return c.Err(checkType)
+	}) {
+		return choose(common.StorageTypeCSVIndexed, "CSV file is loaded into memory once for instant lookups")
+	}
+
+	// The remaining storage types scan the whole file on every lookup
+	if unindexedSQLite {
+		return choose(common.StorageTypeSQLite, "SQLite database has no index on the hash column, but no faster file is usable")
+	}
+	if probe(common.StorageTypeJSONL, func() error {
+		c := jsonl.New(dir)
+		defer c.Close()
+		return c.Err(checkType)
+	}) {
+		return choose(common.StorageTypeJSONL, "JSON Lines file is the only usable file, every lookup scans it")
+	}
+
+	if len(detection.Skipped) > 0 {
+		return nil, fmt.Errorf("%w for %s check in %s: %w", ErrNoUsableStorage, checkType, dir, errors.Join(detection.Skipped...))
+	}
+	return nil, fmt.Errorf("%w for %s check in %s", ErrNoUsableStorage, checkType, dir)
+}
@@ -0,0 +1,163 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/checker/binary"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func TestDetect(t *testing.T) {
+	remove := func(filenames ...string) func(t *testing.T, dir string) {
+		return func(t *testing.T, dir string) {
+			t.Helper()
+			for _, filename := range filenames {
+				require.NoError(t, os.Remove(filepath.Join(dir, filename)))
+			}
+		}
+	}
+	corrupt := func(filename string) func(t *testing.T, dir string) {
+		return func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte("corrupt"), 0o600))
+		}
+	}
+
+	tests := []struct {
+		name        string
+		checkType   common.CheckType
+		setup       []func(t *testing.T, dir string)
+		want        common.StorageType
+		wantSkipped []string
+	}{
+		{
+			name:      "Constant database",
+			checkType: common.CheckTypeUser,
+			setup: []func(t *testing.T, dir string){func(t *testing.T, dir string) {
+				t.Helper()
+				_, err := GenerateCDB(dir, common.CheckTypeUser, common.StorageTypeCSV)
+				require.NoError(t, err)
+			}},
+			want: common.StorageTypeCDB,
+		},
+		{
+			name:        "Corrupt constant database",
+			checkType:   common.CheckTypeUser,
+			setup:       []func(t *testing.T, dir string){corrupt("users.cdb")},
+			want:        common.StorageTypeSQLite,
+			wantSkipped: []string{"users.cdb"},
+		},
+		{
+			name:      "Friends use the user files",
+			checkType: common.CheckTypeFriends,
+			want:      common.StorageTypeSQLite,
+		},
+		{
+			name:      "Unsorted binary file",
+			checkType: common.CheckTypeGroup,
+			setup:     []func(t *testing.T, dir string){remove("groups.db")},
+			want:      common.StorageTypeBinaryIndexed,
+		},
+		{
+			name:      "Sorted binary file",
+			checkType: common.CheckTypeUser,
+			setup: []func(t *testing.T, dir string){remove("users.db"), func(t *testing.T, dir string) {
+				t.Helper()
+				var buf bytes.Buffer
+				require.NoError(t, binary.WriteV2(&buf, 32, []binary.Record{{Hash: testHash(1), Status: "confirmed"}}))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "users.bin"), buf.Bytes(), 0o600))
+			}},
+			want: common.StorageTypeBinary,
+		},
+		{
+			name:      "CSV file",
+			checkType: common.CheckTypeUser,
+			setup:     []func(t *testing.T, dir string){remove("users.db", "users.bin")},
+			want:      common.StorageTypeCSVIndexed,
+		},
+		{
+			name:      "CSV file before unindexed SQLite database",
+			checkType: common.CheckTypeUser,
+			setup:     []func(t *testing.T, dir string){remove("users.bin"), dropHashIndex},
+			want:      common.StorageTypeCSVIndexed,
+		},
+		{
+			name:        "Unindexed SQLite database",
+			checkType:   common.CheckTypeUser,
+			setup:       []func(t *testing.T, dir string){remove("users.bin"), corrupt("users.csv"), dropHashIndex},
+			want:        common.StorageTypeSQLite,
+			wantSkipped: []string{"users.csv"},
+		},
+		{
+			name:      "JSON Lines file",
+			checkType: common.CheckTypeUser,
+			setup: []func(t *testing.T, dir string){remove("users.db", "users.bin", "users.csv"), func(t *testing.T, dir string) {
+				t.Helper()
+				content := `{"hash":"` + testHash(1) + `","status":"confirmed","reason":"a; b","confidence":0.9}` + "\n"
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "users.jsonl"), []byte(content), 0o600))
+			}},
+			want: common.StorageTypeJSONL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupVerifyExport(t)
+			for _, setup := range tt.setup {
+				setup(t, dir)
+			}
+
+			detection, err := Detect(dir, tt.checkType)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, detection.StorageType)
+			assert.NotEmpty(t, detection.Reason)
+			require.Len(t, detection.Skipped, len(tt.wantSkipped))
+			for i, filename := range tt.wantSkipped {
+				assert.Contains(t, detection.Skipped[i].Error(), filename)
+			}
+
+			// The chosen storage type opens the export
+			checker, err := New(dir, detection.StorageType)
+			require.NoError(t, err)
+			defer checker.Close()
+			_, err = checker.GetHashCount(context.Background(), tt.checkType)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDetect_NoUsableStorage(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Detect(dir, common.CheckTypeUser)
+	require.ErrorIs(t, err, ErrNoUsableStorage)
+
+	// Files that cannot be opened are named in the error
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.bin"), []byte("xx"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.jsonl"), []byte("not json\n"), 0o600))
+	_, err = Detect(dir, common.CheckTypeUser)
+	require.ErrorIs(t, err, ErrNoUsableStorage)
+	assert.ErrorContains(t, err, "users.bin")
+	assert.ErrorContains(t, err, "users.jsonl")
+}
+
+// dropHashIndex recreates the users table of the export without its primary key.
+func dropHashIndex(t *testing.T, dir string) {
+	t.Helper()
+	conn, err := sqlite.OpenConn(filepath.Join(dir, "users.db"), sqlite.OpenReadWrite)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, sqlitex.ExecScript(conn, `
+		CREATE TABLE copy (hash TEXT, status TEXT NOT NULL, reason TEXT NOT NULL, confidence REAL NOT NULL);
+		INSERT INTO copy SELECT * FROM users;
+		DROP TABLE users;
+		ALTER TABLE copy RENAME TO users;
+	`))
+}
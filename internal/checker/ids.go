@@ -0,0 +1,41 @@
+package checker
+
+import (
+	"context"
+	"slices"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// checkIDsChunkSize is the number of IDs CheckIDs hashes before looking them up.
+const checkIDsChunkSize = 64
+
+// CheckIDs hashes the IDs and looks them up in chunks. When the context is done, hashing and lookups
+// stop and the results of the chunks already looked up are returned in order along with the context
+// error. The results therefore cover a prefix of the IDs, and are nil only if the lookup failed.
+func CheckIDs(
+	ctx context.Context, c Checker, checkType common.CheckType, ids []uint64, hash func(id uint64) string,
+) ([]*common.CheckResult, error) {
+	results := make([]*common.CheckResult, 0, len(ids))
+	for chunk := range slices.Chunk(ids, checkIDsChunkSize) {
+		// Hash each ID of the chunk
+		hashes := make([]string, 0, len(chunk))
+		for _, id := range chunk {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			hashes = append(hashes, hash(id))
+		}
+
+		found, err := c.CheckMany(ctx, checkType, hashes)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return results, ctxErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "users", []testRecord{
+		{hash: testHash(2), status: "confirmed", reason: "reason", confidence: 1},
+	})
+	c, err := New(dir, common.StorageTypeCSV)
+	require.NoError(t, err)
+
+	// All IDs are checked
+	results, err := CheckIDs(context.Background(), c, common.CheckTypeUser, []uint64{1, 2, 3}, func(id uint64) string {
+		return testHash(int(id))
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Found)
+	assert.True(t, results[1].Found)
+	assert.False(t, results[2].Found)
+
+	// Cancelling while hashing returns the results of the chunks looked up so far
+	ids := make([]uint64, checkIDsChunkSize+10)
+	for i := range ids {
+		ids[i] = uint64(i + 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err = CheckIDs(ctx, c, common.CheckTypeUser, ids, func(id uint64) string {
+		if id == checkIDsChunkSize+5 {
+			cancel()
+		}
+		return testHash(int(id))
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, checkIDsChunkSize)
+	assert.True(t, results[1].Found)
+
+	// Cancelling during a lookup stops it instead of finishing it
+	ctx, cancel = context.WithCancel(context.Background())
+	cancelling := &cancellingChecker{Checker: c, cancel: cancel}
+	results, err = CheckIDs(ctx, cancelling, common.CheckTypeUser, []uint64{1, 2, 3}, func(id uint64) string {
+		return testHash(int(id))
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+	assert.ErrorIs(t, cancelling.err, context.Canceled)
+}
+
+// cancellingChecker cancels the lookup context before passing the lookup on.
+type cancellingChecker struct {
+	Checker
+	cancel context.CancelFunc
+	err    error
+}
+
+func (c *cancellingChecker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	c.cancel()
+	results, err := c.Checker.CheckMany(ctx, checkType, hashes)
+	c.err = err
+	return results, err
+}
@@ -0,0 +1,251 @@
+package indexed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Loader calls fn with every valid record in the storage file for the check type,
+// returning corrupt records as problems, and stops when fn returns common.ErrStopWalk.
+// The Walk functions of the file backends are loaders.
+type Loader func(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error)
+
+// LoadStats describes an index loaded into memory.
+type LoadStats struct {
+	Records  int
+	Duration time.Duration
+	Bytes    uint64 // Estimated memory held by the index
+}
+
+// String describes the index, e.g. "1000 hashes loaded in 12ms (95.3 KB)".
+func (s LoadStats) String() string {
+	return fmt.Sprintf("%d hashes loaded in %s (%s)", s.Records, s.Duration.Round(time.Millisecond), formatBytes(s.Bytes))
+}
+
+// formatBytes formats a byte count using binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
+
+// recordOverhead is the approximate memory used per record on 64-bit platforms besides its strings:
+// the map entry holding the key header, value pointer and control byte, and the result it points to.
+const recordOverhead = 16 + 8 + 1 + 48
+
+// index holds the records of a single check type.
+type index struct {
+	done    chan struct{} // Closed once the load finishes
+	records map[string]*common.CheckResult
+	stats   LoadStats
+	err     error // Set if the file could not be loaded
+}
+
+// Checker implements the common.Checker interface by loading a storage file into memory once
+// and answering lookups from a map keyed by hash.
+type Checker struct {
+	dir     string
+	load    Loader
+	ctx     context.Context // Cancelled by Close to stop loads in progress
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	closed  bool
+	indexes map[common.CheckType]*index
+}
+
+// New creates a new indexed checker that loads the files in dir with load.
+// Each file is loaded on its first lookup, and a file that cannot be loaded returns its error on every lookup.
+func New(dir string, load Loader) *Checker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Checker{
+		dir:     dir,
+		load:    load,
+		ctx:     ctx,
+		cancel:  cancel,
+		indexes: make(map[common.CheckType]*index),
+	}
+}
+
+// index returns the index for the check type, starting its load if needed.
+// The load runs in the background without holding the lock, so a caller whose context ends stops
+// waiting for it while the load carries on for later lookups.
+func (c *Checker) index(ctx context.Context, checkType common.CheckType) (*index, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Friends checks use the user file
+	if checkType != common.CheckTypeGroup {
+		checkType = common.CheckTypeUser
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, common.ErrCheckerClosed
+	}
+	idx, ok := c.indexes[checkType]
+	if !ok {
+		idx = &index{done: make(chan struct{})}
+		c.indexes[checkType] = idx
+		go c.build(idx, checkType)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-idx.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if idx.err != nil {
+		return nil, idx.err
+	}
+	return idx, nil
+}
+
+// build loads every record of the check type into the index and closes its done channel.
+// Any corrupt record fails the load so that lookups never silently miss a hash.
+func (c *Checker) build(idx *index, checkType common.CheckType) {
+	defer close(idx.done)
+
+	start := time.Now()
+	records := make(map[string]*common.CheckResult)
+	var bytes uint64
+
+	// Share the status and reason strings, which repeat across records
+	interned := make(map[string]string)
+	intern := func(s string) string {
+		if v, ok := interned[s]; ok {
+			return v
+		}
+		interned[s] = s
+		bytes += uint64(len(s))
+		return s
+	}
+
+	problems, err := c.load(c.dir, checkType, func(hash string, result *common.CheckResult) error {
+		// Stop loading once the checker is closed
+		if c.ctx.Err() != nil {
+			return common.ErrStopWalk
+		}
+
+		// Keep the first record of a duplicated hash, as a linear scan would
+		if _, ok := records[hash]; ok {
+			return nil
+		}
+
+		result.Status = intern(result.Status)
+		result.Reason = intern(result.Reason)
+		records[hash] = result
+		bytes += uint64(len(hash)) + recordOverhead
+		return nil
+	})
+	if c.ctx.Err() != nil {
+		err = common.ErrCheckerClosed
+	}
+	if err == nil && len(problems) > 0 {
+		err = fmt.Errorf("failed to load index: %d corrupt records, first: %w", len(problems), problems[0])
+	}
+	if err != nil {
+		idx.err = err
+		return
+	}
+
+	idx.records = records
+	idx.stats = LoadStats{Records: len(records), Duration: time.Since(start), Bytes: bytes}
+}
+
+// lookup returns a copy of the record for the hash, so callers cannot modify the index.
+func (idx *index) lookup(hash string) *common.CheckResult {
+	result := common.CheckResult{}
+	if record, ok := idx.records[hash]; ok {
+		result = *record
+	}
+	return &result
+}
+
+// Check verifies if the given ID exists in the index.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	idx, err := c.index(ctx, checkType)
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.lookup(id), nil
+}
+
+// CheckMany verifies which of the given hashes exist in the index.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	idx, err := c.index(ctx, checkType)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*common.CheckResult, len(hashes))
+	for i, hash := range hashes {
+		results[i] = idx.lookup(hash)
+	}
+
+	return results, nil
+}
+
+// GetHashCount returns the number of hashes in the index.
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	idx, err := c.index(ctx, checkType)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(idx.stats.Records), nil //nolint:gosec
+}
+
+// Each calls fn with a copy of every record in the index, in no particular order, stopping at the first error.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	idx, err := c.index(ctx, checkType)
+	if err != nil {
+		return err
+	}
+
+	for hash := range idx.records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(hash, idx.lookup(hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadStats returns the load time and memory use of the index for the check type, loading it if needed.
+func (c *Checker) LoadStats(ctx context.Context, checkType common.CheckType) (LoadStats, error) {
+	idx, err := c.index(ctx, checkType)
+	if err != nil {
+		return LoadStats{}, err
+	}
+	return idx.stats, nil
+}
+
+// Close releases the indexes and stops loads in progress. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	c.cancel()
+	clear(c.indexes)
+	return nil
+}
@@ -0,0 +1,243 @@
+package indexed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errLoad = errors.New("load failed")
+
+// testLoader returns a loader that serves the records of each check type and counts its calls.
+func testLoader(records map[common.CheckType]map[string]*common.CheckResult, calls *int) Loader {
+	return func(_ string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+		*calls++
+		for hash, result := range records[checkType] {
+			record := *result
+			if err := fn(hash, &record); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	calls := 0
+	checker := New("test_dir", testLoader(map[common.CheckType]map[string]*common.CheckResult{
+		common.CheckTypeUser: {
+			"aa": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+			"bb": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.5},
+		},
+		common.CheckTypeGroup: {
+			"cc": {Found: true, Status: "flagged", Reason: "spam", Confidence: 0.7},
+		},
+	}, &calls))
+
+	tests := []struct {
+		name       string
+		checkType  common.CheckType
+		hash       string
+		wantFound  bool
+		wantStatus string
+	}{
+		{name: "User found", checkType: common.CheckTypeUser, hash: "aa", wantFound: true, wantStatus: "banned"},
+		{name: "User not found", checkType: common.CheckTypeUser, hash: "cc"},
+		{name: "Friends use user index", checkType: common.CheckTypeFriends, hash: "bb", wantFound: true, wantStatus: "banned"},
+		{name: "Group found", checkType: common.CheckTypeGroup, hash: "cc", wantFound: true, wantStatus: "flagged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFound, result.Found)
+			assert.Equal(t, tt.wantStatus, result.Status)
+		})
+	}
+
+	// Each file is loaded only once
+	assert.Equal(t, 2, calls)
+
+	// Results are copies that do not change the index
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.NoError(t, err)
+	result.Status = "changed"
+	result, err = checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "banned", result.Status)
+}
+
+func TestChecker_CheckMany(t *testing.T) {
+	calls := 0
+	checker := New("test_dir", testLoader(map[common.CheckType]map[string]*common.CheckResult{
+		common.CheckTypeUser: {"aa": {Found: true, Status: "banned"}},
+	}, &calls))
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"bb", "aa", "aa"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Found)
+	assert.True(t, results[1].Found)
+	assert.True(t, results[2].Found)
+}
+
+func TestChecker_LoadStats(t *testing.T) {
+	calls := 0
+	checker := New("test_dir", testLoader(map[common.CheckType]map[string]*common.CheckResult{
+		common.CheckTypeUser: {
+			"aa": {Found: true, Status: "banned", Reason: "violation"},
+			"bb": {Found: true, Status: "banned", Reason: "violation"},
+		},
+	}, &calls))
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	stats, err := checker.LoadStats(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Records)
+	// The repeated status and reason are only counted once
+	assert.Equal(t, uint64(2*(2+recordOverhead)+len("banned")+len("violation")), stats.Bytes)
+	assert.Contains(t, stats.String(), "2 hashes loaded in")
+	assert.Equal(t, 1, calls)
+}
+
+func TestChecker_LoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		load    Loader
+		wantErr error
+	}{
+		{
+			name: "File cannot be read",
+			load: func(string, common.CheckType, common.RecordFunc) ([]error, error) {
+				return nil, errLoad
+			},
+			wantErr: errLoad,
+		},
+		{
+			name: "Corrupt records",
+			load: func(_ string, _ common.CheckType, fn common.RecordFunc) ([]error, error) {
+				require.NoError(t, fn("aa", &common.CheckResult{Found: true}))
+				return []error{errLoad}, nil
+			},
+			wantErr: errLoad,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := New("test_dir", tt.load)
+
+			_, err := checker.Check(context.Background(), common.CheckTypeUser, "aa")
+			require.ErrorIs(t, err, tt.wantErr)
+			_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChecker_Close(t *testing.T) {
+	calls := 0
+	checker := New("test_dir", testLoader(nil, &calls))
+
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.NoError(t, err)
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close(), "Close should be idempotent")
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.LoadStats(context.Background(), common.CheckTypeUser)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
+
+func TestChecker_CancelDuringLoad(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	checker := New("test_dir", func(_ string, _ common.CheckType, fn common.RecordFunc) ([]error, error) {
+		close(started)
+		<-release
+		return nil, fn("aa", &common.CheckResult{Found: true, Status: "flagged"})
+	})
+	defer checker.Close()
+
+	// A caller whose context ends stops waiting for the load
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := checker.Check(ctx, common.CheckTypeUser, "aa")
+		errs <- err
+	}()
+	<-started
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	_, err := checker.Check(timeout, common.CheckTypeUser, "aa")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The load carries on and serves later lookups
+	close(release)
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+}
+
+func TestChecker_CloseDuringLoad(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	stopped := make(chan error, 1)
+	checker := New("test_dir", func(_ string, _ common.CheckType, fn common.RecordFunc) ([]error, error) {
+		close(started)
+		<-release
+		err := fn("aa", &common.CheckResult{Found: true})
+		stopped <- err
+		return nil, err
+	})
+
+	go checker.Check(context.Background(), common.CheckTypeUser, "aa") //nolint:errcheck
+	<-started
+	require.NoError(t, checker.Close())
+	close(release)
+	require.ErrorIs(t, <-stopped, common.ErrStopWalk)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "2.0 MB", formatBytes(2<<20))
+	assert.Equal(t, "3.0 GB", formatBytes(3<<30))
+}
+
+func TestChecker_Each(t *testing.T) {
+	calls := 0
+	checker := New("test_dir", testLoader(map[common.CheckType]map[string]*common.CheckResult{
+		common.CheckTypeUser: {
+			"aa": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+			"bb": {Found: true, Status: "flagged", Reason: "spam", Confidence: 0.5},
+		},
+	}, &calls))
+
+	results := make(map[string]*common.CheckResult)
+	err := checker.Each(context.Background(), common.CheckTypeFriends, func(hash string, result *common.CheckResult) error {
+		result.Status = "modified" // Records are copies
+		results[hash] = result
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, calls)
+
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "banned", result.Status)
+}
@@ -0,0 +1,327 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+const (
+	// ctxCheckInterval is the number of lines read between context checks.
+	ctxCheckInterval = 1024
+
+	// maxLineSize is the longest line accepted, which bounds the memory used per record.
+	maxLineSize = 16 * 1024 * 1024
+)
+
+var ErrInvalidFormat = errors.New("invalid JSON Lines format")
+
+// record is a single line of a JSON Lines file. Fields other than these are ignored,
+// so exports can carry extra metadata.
+type record struct {
+	Hash       string          `json:"hash"`
+	Status     string          `json:"status"`
+	Reason     json.RawMessage `json:"reason"`
+	Confidence *float64        `json:"confidence"`
+}
+
+// hashOnly is decoded from every line during lookups, so that only matching lines are fully decoded.
+type hashOnly struct {
+	Hash string `json:"hash"`
+}
+
+// exportFile is an open JSON Lines file for a single check type.
+type exportFile struct {
+	file *os.File
+	size int64
+	err  error // Set if the file could not be opened
+}
+
+// Checker implements the common.Checker interface for JSON Lines storage.
+// The files are opened once and each lookup reads through its own section reader.
+type Checker struct {
+	dir    string
+	mu     sync.RWMutex
+	users  *exportFile
+	groups *exportFile
+}
+
+// New creates a new JSON Lines checker and opens the user and group files.
+// A file that cannot be opened returns its error on every lookup.
+func New(dir string) *Checker {
+	return &Checker{
+		dir:    dir,
+		users:  openFile(filepath.Join(dir, Filename(common.CheckTypeUser))),
+		groups: openFile(filepath.Join(dir, Filename(common.CheckTypeGroup))),
+	}
+}
+
+// Filename returns the name of the JSON Lines file for the check type.
+func Filename(checkType common.CheckType) string {
+	if checkType == common.CheckTypeGroup {
+		return "groups.jsonl"
+	}
+	return "users.jsonl"
+}
+
+// openFile opens a JSON Lines file and validates its first record.
+func openFile(path string) *exportFile {
+	f := &exportFile{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		f.err = fmt.Errorf("failed to open file: %w", err)
+		return f
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		f.err = fmt.Errorf("failed to get file stats: %w", err)
+		return f
+	}
+
+	// Validate the first record, if any
+	f.file, f.size = file, stat.Size()
+	err = f.each(context.Background(), func(line int, data []byte) (bool, error) {
+		_, _, err := parseRecord(line, data)
+		return false, err
+	})
+	if err != nil {
+		file.Close()
+		f.file, f.err = nil, err
+		return f
+	}
+
+	return f
+}
+
+// each calls fn with the line number and contents of every non-empty line until fn returns false.
+// The contents are only valid until fn returns.
+func (f *exportFile) each(ctx context.Context, fn func(line int, data []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(io.NewSectionReader(f.file, 0, f.size))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if line%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if more, err := fn(line, data); err != nil || !more {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	return nil
+}
+
+// parseRecord decodes a line into its hash and check result.
+func parseRecord(line int, data []byte) (string, *common.CheckResult, error) {
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return "", nil, fmt.Errorf("line %d: %w: %w", line, ErrInvalidFormat, err)
+	}
+	if r.Hash == "" {
+		return "", nil, fmt.Errorf("line %d: %w: missing hash", line, ErrInvalidFormat)
+	}
+	if r.Confidence == nil {
+		return "", nil, fmt.Errorf("line %d: %w: missing confidence", line, ErrInvalidFormat)
+	}
+
+	reason, err := parseReason(r.Reason)
+	if err != nil {
+		return "", nil, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	return r.Hash, &common.CheckResult{
+		Found:      true,
+		Status:     r.Status,
+		Reason:     reason,
+		Confidence: *r.Confidence,
+	}, nil
+}
+
+// parseReason converts the reason of a record to text. A list of reasons is joined with "; ",
+// matching the other formats, and any other structure is kept as compact JSON so nothing is lost.
+func parseReason(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var reason string
+	if err := json.Unmarshal(raw, &reason); err == nil {
+		return reason, nil
+	}
+
+	var reasons []string
+	if err := json.Unmarshal(raw, &reasons); err == nil {
+		return strings.Join(reasons, "; "), nil
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", fmt.Errorf("%w: invalid reason: %w", ErrInvalidFormat, err)
+	}
+	return compact.String(), nil
+}
+
+// exportFile returns the open file for the check type. The caller must hold the read lock.
+func (c *Checker) exportFile(checkType common.CheckType) (*exportFile, error) {
+	f := c.users
+	if checkType == common.CheckTypeGroup {
+		f = c.groups
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.file == nil {
+		return nil, common.ErrCheckerClosed
+	}
+	return f, nil
+}
+
+// Check verifies if the given ID exists in the JSON Lines file.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	results, err := c.CheckMany(ctx, checkType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CheckMany verifies which of the given hashes exist in the JSON Lines file using a single pass.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Map each hash to its positions in the results
+	results := make([]*common.CheckResult, len(hashes))
+	positions := make(map[string][]int, len(hashes))
+	for i, hash := range hashes {
+		results[i] = &common.CheckResult{}
+		positions[hash] = append(positions[hash], i)
+	}
+	if len(hashes) == 0 {
+		return results, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read each line until every hash has been found
+	err = f.each(ctx, func(line int, data []byte) (bool, error) {
+		var key hashOnly
+		if err := json.Unmarshal(data, &key); err != nil {
+			return false, fmt.Errorf("line %d: %w: %w", line, ErrInvalidFormat, err)
+		}
+		indices, ok := positions[key.Hash]
+		if !ok {
+			return true, nil
+		}
+
+		_, result, err := parseRecord(line, data)
+		if err != nil {
+			return false, err
+		}
+		for _, i := range indices {
+			results[i] = result
+		}
+		delete(positions, key.Hash)
+		return len(positions) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetHashCount returns the number of records in the JSON Lines file.
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	var count uint64
+	err := c.Each(ctx, checkType, func(string, *common.CheckResult) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Each calls fn with every record in the JSON Lines file, stopping at the first error.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	f, err := c.exportFile(checkType)
+	if err != nil {
+		return err
+	}
+
+	return f.each(ctx, func(line int, data []byte) (bool, error) {
+		hash, result, err := parseRecord(line, data)
+		if err != nil {
+			return false, err
+		}
+		return true, fn(hash, result)
+	})
+}
+
+// Err returns the error that prevents lookups in the file for the check type, or nil if it opened.
+func (c *Checker) Err(checkType common.CheckType) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, err := c.exportFile(checkType)
+	return err
+}
+
+// Close closes the files. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, f := range []*exportFile{c.users, c.groups} {
+		if f.file == nil {
+			continue
+		}
+		if err := f.file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close file: %w", err))
+		}
+		f.file = nil
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,201 @@
+package jsonl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestFiles(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string][]string{
+		"users.jsonl": {
+			`{"hash":"aa01","status":"banned","reason":"violation","confidence":0.95}`,
+			``,
+			`{"hash":"aa02","status":"flagged","reason":["first","second"],"confidence":0.5,"source":"pipeline"}`,
+			`{"hash":"aa03","status":"flagged","reason":{"type":"description", "score": 3},"confidence":1}`,
+		},
+		"groups.jsonl": {},
+	}
+
+	for filename, lines := range files {
+		content := strings.Join(lines, "\n")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(content), 0o600))
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+	checker := New(tempDir)
+	defer checker.Close()
+
+	tests := []struct {
+		name      string
+		checkType common.CheckType
+		hash      string
+		want      *common.CheckResult
+	}{
+		{
+			name:      "String reason",
+			checkType: common.CheckTypeUser,
+			hash:      "aa01",
+			want:      &common.CheckResult{Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+		},
+		{
+			name:      "List of reasons with extra fields",
+			checkType: common.CheckTypeFriends,
+			hash:      "aa02",
+			want:      &common.CheckResult{Found: true, Status: "flagged", Reason: "first; second", Confidence: 0.5},
+		},
+		{
+			name:      "Structured reason",
+			checkType: common.CheckTypeUser,
+			hash:      "aa03",
+			want:      &common.CheckResult{Found: true, Status: "flagged", Reason: `{"type":"description","score":3}`, Confidence: 1},
+		},
+		{
+			name:      "Not found",
+			checkType: common.CheckTypeUser,
+			hash:      "ffff",
+			want:      &common.CheckResult{},
+		},
+		{
+			name:      "Empty group file",
+			checkType: common.CheckTypeGroup,
+			hash:      "aa01",
+			want:      &common.CheckResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestChecker_CheckMany(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+	checker := New(tempDir)
+	defer checker.Close()
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"aa03", "ffff", "aa03"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Found)
+	assert.False(t, results[1].Found)
+	assert.Equal(t, results[0], results[2])
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+
+	count, err = checker.GetHashCount(context.Background(), common.CheckTypeGroup)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestChecker_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "Not JSON", content: "hash,status,reason,confidence\n", wantErr: "line 1"},
+		{name: "Missing hash", content: `{"status":"banned","confidence":1}`, wantErr: "missing hash"},
+		{name: "Missing confidence", content: `{"hash":"aa01","status":"banned"}`, wantErr: "missing confidence"},
+		{name: "Wrong field type", content: `{"hash":"aa01","confidence":"high"}`, wantErr: "line 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.jsonl"), []byte(tt.content), 0o600))
+
+			checker := New(tempDir)
+			require.ErrorIs(t, checker.Err(common.CheckTypeUser), ErrInvalidFormat)
+			_, err := checker.Check(context.Background(), common.CheckTypeUser, "aa01")
+			assert.ErrorIs(t, err, ErrInvalidFormat)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	// Corrupt lines after the first are reported when they are read
+	tempDir := t.TempDir()
+	content := `{"hash":"aa01","status":"banned","reason":"violation","confidence":0.95}` + "\n{not json\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.jsonl"), []byte(content), 0o600))
+
+	checker := New(tempDir)
+	require.NoError(t, checker.Err(common.CheckTypeUser))
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "aa01")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "ffff")
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = New(t.TempDir()).Check(context.Background(), common.CheckTypeUser, "aa01")
+	assert.Error(t, err)
+}
+
+func TestChecker_Close(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	checker := New(tempDir)
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close(), "Close should be idempotent")
+
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, "aa01")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	err = checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error { return nil })
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
+
+func TestWalk(t *testing.T) {
+	tempDir := t.TempDir()
+	content := strings.Join([]string{
+		`{"hash":"aa01","status":"banned","reason":"violation","confidence":0.95}`,
+		`{"hash":"not-hex","status":"banned","reason":"violation","confidence":0.95}`,
+		`{"hash":"aa02","status":"banned","reason":"violation","confidence":2}`,
+		`{broken`,
+		`{"hash":"aa03","status":"flagged","reason":["a","b"],"confidence":0.5}`,
+	}, "\n")
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "users.jsonl"), []byte(content), 0o600))
+
+	var hashes []string
+	problems, err := Walk(tempDir, common.CheckTypeUser, func(hash string, _ *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aa01", "aa03"}, hashes)
+	require.Len(t, problems, 3)
+	assert.ErrorIs(t, problems[0], common.ErrInvalidRecordHash)
+	assert.Contains(t, problems[0].Error(), "line 2")
+	assert.ErrorIs(t, problems[1], common.ErrInvalidConfidence)
+	assert.ErrorIs(t, problems[2], ErrInvalidFormat)
+	assert.Contains(t, problems[2].Error(), "line 4")
+
+	// Returning ErrStopWalk ends the walk at the first valid record
+	hashes = nil
+	problems, err = Walk(tempDir, common.CheckTypeUser, func(hash string, _ *common.CheckResult) error {
+		hashes = append(hashes, hash)
+		return common.ErrStopWalk
+	})
+	require.ErrorIs(t, err, common.ErrStopWalk)
+	assert.Empty(t, problems)
+	assert.Equal(t, []string{"aa01"}, hashes)
+
+	_, err = Walk(tempDir, common.CheckTypeGroup, func(string, *common.CheckResult) error { return nil })
+	assert.Error(t, err)
+}
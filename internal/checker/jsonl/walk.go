@@ -0,0 +1,56 @@
+package jsonl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Walk fully parses the JSON Lines file for the check type, calling fn with the hash of every valid record.
+// Corrupt records, and records rejected by fn, are returned as problems with their line number, and
+// parsing continues past them.
+// An error is returned if the file cannot be read at all or fn stops the walk with common.ErrStopWalk.
+func Walk(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+	file, err := os.Open(filepath.Join(dir, Filename(checkType)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	var problems []error
+	f := &exportFile{file: file, size: stat.Size()}
+	err = f.each(context.Background(), func(line int, data []byte) (bool, error) {
+		hash, result, err := parseRecord(line, data)
+		if err != nil {
+			problems = append(problems, err)
+			return true, nil
+		}
+		if err := common.ValidateRecord(hash, result.Confidence); err != nil {
+			problems = append(problems, fmt.Errorf("line %d: %w", line, err))
+			return true, nil
+		}
+
+		err = fn(hash, result)
+		if errors.Is(err, common.ErrStopWalk) {
+			return false, err
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("line %d: %w", line, err))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return problems, err
+	}
+
+	return problems, nil
+}
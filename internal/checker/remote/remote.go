@@ -0,0 +1,334 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Remote checkers look hashes up through the HTTP API of a Rotten-compatible server, so that clients
+// only need the export configuration to hash IDs and never send raw IDs. Every request carries the
+// auth header, if any, and the check type is "user" or "group":
+//
+//	POST {base}/v1/check/{type}  {"hashes": ["..."]}  ->  {"results": [{"found": true, "status": "...", ...}]}
+//	GET  {base}/v1/count/{type}                       ->  {"count": 123}
+//
+// Results are returned in the same order as the hashes. Any status other than 200 is an error.
+// Checkers with a prefix length use the range lookups described in server.go instead of sending hashes.
+const (
+	// DefaultTimeout is the request timeout used when the config does not set one.
+	DefaultTimeout = 10 * time.Second
+
+	// maxBatchSize is the largest number of hashes sent in a single request.
+	maxBatchSize = 1000
+
+	// maxErrorSize is the longest error message read from a failed response.
+	maxErrorSize = 1024
+)
+
+var (
+	ErrInvalidConfig    = errors.New("invalid remote checker config")
+	ErrUnavailable      = errors.New("remote checker is unavailable")
+	ErrUnauthorized     = errors.New("remote checker rejected the credentials")
+	ErrUnexpectedStatus = errors.New("unexpected status from remote checker")
+	ErrInvalidResponse  = errors.New("invalid response from remote checker")
+	ErrEachNotSupported = errors.New("remote checkers cannot list the records of an export")
+)
+
+// Config configures the server a remote checker calls.
+type Config struct {
+	// BaseURL is the http or https URL of the server, which may include a path prefix.
+	BaseURL string
+
+	// Timeout limits each request. DefaultTimeout is used if it is zero.
+	Timeout time.Duration
+
+	// AuthHeader is an optional header sent with every request, in the form "Name: value",
+	// such as "Authorization: Bearer token".
+	AuthHeader string
+
+	// PrefixLength, if set, makes lookups send only the first PrefixLength characters of each hash
+	// and find the hash among the records returned for that range, so the server cannot tell which
+	// hash was checked. It must be at least the minimum prefix length of the server.
+	PrefixLength int
+}
+
+// Checker implements the common.Checker interface by calling a remote server.
+type Checker struct {
+	client     *http.Client
+	baseURL    *url.URL
+	authName   string
+	authValue  string
+	prefixLen  int
+	mu         sync.RWMutex
+	closed     bool
+	closeIdles func()
+}
+
+// checkRequest is the body of a check request.
+type checkRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// checkResponse is the body of a successful check response.
+type checkResponse struct {
+	Results []*common.CheckResult `json:"results"`
+}
+
+// countResponse is the body of a successful count response.
+type countResponse struct {
+	Count uint64 `json:"count"`
+}
+
+// New creates a new remote checker for the server in the config.
+// No request is made until the first lookup.
+func New(cfg Config) (*Checker, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("%w: base URL %q must be an http or https URL", ErrInvalidConfig, cfg.BaseURL)
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("%w: timeout must not be negative", ErrInvalidConfig)
+	}
+	if cfg.PrefixLength < 0 {
+		return nil, fmt.Errorf("%w: prefix length must not be negative", ErrInvalidConfig)
+	}
+	authName, authValue, err := parseAuthHeader(cfg.AuthHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+
+	return &Checker{
+		client:     &http.Client{Timeout: timeout, Transport: transport},
+		baseURL:    baseURL,
+		authName:   authName,
+		authValue:  authValue,
+		prefixLen:  cfg.PrefixLength,
+		closeIdles: transport.CloseIdleConnections,
+	}, nil
+}
+
+// Check verifies if the given hash exists in the remote export.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	results, err := c.CheckMany(ctx, checkType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CheckMany verifies which of the given hashes exist in the remote export, sending them in batches.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return nil, common.ErrCheckerClosed
+	}
+	if c.prefixLen > 0 {
+		return c.checkRanges(ctx, checkType, hashes)
+	}
+
+	results := make([]*common.CheckResult, 0, len(hashes))
+	for start := 0; start < len(hashes); start += maxBatchSize {
+		batch := hashes[start:min(start+maxBatchSize, len(hashes))]
+
+		var resp checkResponse
+		if err := c.do(ctx, http.MethodPost, "check", checkType, &checkRequest{Hashes: batch}, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Results) != len(batch) {
+			return nil, fmt.Errorf("%w: %d results for %d hashes", ErrInvalidResponse, len(resp.Results), len(batch))
+		}
+		for _, result := range resp.Results {
+			if result == nil {
+				return nil, fmt.Errorf("%w: missing result", ErrInvalidResponse)
+			}
+		}
+
+		results = append(results, resp.Results...)
+	}
+
+	return results, nil
+}
+
+// checkRanges looks the hashes up with one range lookup per distinct prefix.
+// The results are in the same order as the hashes.
+func (c *Checker) checkRanges(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	// Group the hashes by prefix, keeping the order they were first seen in
+	var prefixes []string
+	positions := make(map[string][]int)
+	for i, hash := range hashes {
+		if len(hash) < c.prefixLen {
+			return nil, fmt.Errorf("%w: %q has fewer than %d characters", ErrHashTooShort, hash, c.prefixLen)
+		}
+		prefix := strings.ToLower(hash[:c.prefixLen])
+		if _, ok := positions[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		positions[prefix] = append(positions[prefix], i)
+	}
+
+	results := make([]*common.CheckResult, len(hashes))
+	for _, prefix := range prefixes {
+		if err := validatePrefix(prefix); err != nil {
+			return nil, err
+		}
+
+		var resp rangeResponse
+		if err := c.do(ctx, http.MethodGet, "range", checkType, nil, &resp, prefix); err != nil {
+			return nil, err
+		}
+
+		// Match the hashes against the records of the range
+		for _, i := range positions[prefix] {
+			results[i] = &common.CheckResult{}
+			for _, record := range resp.Records {
+				if strings.EqualFold(record.Hash, hashes[i]) {
+					results[i] = &common.CheckResult{
+						Found:      true,
+						Status:     record.Status,
+						Reason:     record.Reason,
+						Confidence: record.Confidence,
+					}
+					break
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// GetHashCount returns the number of records in the remote export.
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return 0, common.ErrCheckerClosed
+	}
+
+	var resp countResponse
+	if err := c.do(ctx, http.MethodGet, "count", checkType, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// Each is not supported, since the API only answers lookups of known hashes.
+func (c *Checker) Each(_ context.Context, _ common.CheckType, _ common.RecordFunc) error {
+	return ErrEachNotSupported
+}
+
+// Close releases the idle connections to the server. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	c.closeIdles()
+	return nil
+}
+
+// do sends a request to the endpoint for the check type, followed by any extra path elements,
+// and decodes the JSON response into out.
+func (c *Checker) do(
+	ctx context.Context, method, endpoint string, checkType common.CheckType, body, out any, elem ...string,
+) error {
+	if checkType != common.CheckTypeGroup {
+		checkType = common.CheckTypeUser
+	}
+	target := c.baseURL.JoinPath(append([]string{"v1", endpoint, string(checkType)}, elem...)...)
+
+	// Encode the request body, if any
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authName != "" {
+		req.Header.Set(c.authName, c.authValue)
+	}
+
+	// A cancelled lookup is reported as such, anything else means the server could not be reached
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("%w: %s: %w", ErrUnavailable, c.baseURL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+		if text := strings.TrimSpace(string(message)); text != "" {
+			return fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, resp.Status, text)
+		}
+		return fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// parseAuthHeader splits an auth header in the form "Name: value". An empty header is allowed.
+func parseAuthHeader(header string) (string, string, error) {
+	if header == "" {
+		return "", "", nil
+	}
+
+	name, value, ok := strings.Cut(header, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" || value == "" {
+		return "", "", fmt.Errorf("%w: auth header must be in the form \"Name: value\"", ErrInvalidConfig)
+	}
+	return name, value, nil
+}
@@ -0,0 +1,336 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer serves the remote API for a fixed set of flagged user and group hashes.
+type testServer struct {
+	records  map[string]map[string]*common.CheckResult
+	requests []*http.Request
+	batches  [][]string
+}
+
+func newTestServer(t *testing.T, authHeader string) (*testServer, *httptest.Server) {
+	t.Helper()
+	ts := &testServer{
+		records: map[string]map[string]*common.CheckResult{
+			"user": {
+				"aa01": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+				"aa02": {Found: true, Status: "flagged", Reason: "suspicious", Confidence: 0.5},
+			},
+			"group": {
+				"bb01": {Found: true, Status: "locked", Reason: "scam", Confidence: 0.8},
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/check/{type}", func(w http.ResponseWriter, r *http.Request) {
+		var req checkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ts.batches = append(ts.batches, req.Hashes)
+
+		results := make([]*common.CheckResult, len(req.Hashes))
+		for i, hash := range req.Hashes {
+			results[i] = &common.CheckResult{}
+			if result, ok := ts.records[r.PathValue("type")][hash]; ok {
+				results[i] = result
+			}
+		}
+		_ = json.NewEncoder(w).Encode(checkResponse{Results: results})
+	})
+	mux.HandleFunc("GET /api/v1/count/{type}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(countResponse{Count: uint64(len(ts.records[r.PathValue("type")]))})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.requests = append(ts.requests, r)
+		if authHeader != "" {
+			name, value, _ := strings.Cut(authHeader, ": ")
+			if r.Header.Get(name) != value {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return ts, server
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{
+			name: "HTTPS URL with auth header",
+			cfg:  Config{BaseURL: "https://example.com/rotten/", Timeout: time.Second, AuthHeader: "Authorization: Bearer token"},
+		},
+		{
+			name: "HTTP URL with default timeout",
+			cfg:  Config{BaseURL: "http://localhost:8080"},
+		},
+		{
+			name:    "Missing URL",
+			cfg:     Config{},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported scheme",
+			cfg:     Config{BaseURL: "ftp://example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "Negative timeout",
+			cfg:     Config{BaseURL: "https://example.com", Timeout: -time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "Auth header without value",
+			cfg:     Config{BaseURL: "https://example.com", AuthHeader: "Authorization"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := New(tt.cfg)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidConfig)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, checker.Close())
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	ts, server := newTestServer(t, "X-Api-Key: secret")
+	checker, err := New(Config{BaseURL: server.URL + "/api", AuthHeader: "X-Api-Key: secret"})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	tests := []struct {
+		name      string
+		checkType common.CheckType
+		hash      string
+		want      *common.CheckResult
+	}{
+		{
+			name:      "Flagged user",
+			checkType: common.CheckTypeUser,
+			hash:      "aa01",
+			want:      &common.CheckResult{Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+		},
+		{
+			name:      "Friends use the user export",
+			checkType: common.CheckTypeFriends,
+			hash:      "aa02",
+			want:      &common.CheckResult{Found: true, Status: "flagged", Reason: "suspicious", Confidence: 0.5},
+		},
+		{
+			name:      "Flagged group",
+			checkType: common.CheckTypeGroup,
+			hash:      "bb01",
+			want:      &common.CheckResult{Found: true, Status: "locked", Reason: "scam", Confidence: 0.8},
+		},
+		{
+			name:      "Clean user",
+			checkType: common.CheckTypeUser,
+			hash:      "bb01",
+			want:      &common.CheckResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	// Only hashes are sent, with the auth header
+	for _, req := range ts.requests {
+		assert.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	}
+}
+
+func TestChecker_CheckMany(t *testing.T) {
+	ts, server := newTestServer(t, "")
+	checker, err := New(Config{BaseURL: server.URL + "/api/"})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	// Send more hashes than fit in a single request
+	hashes := make([]string, maxBatchSize+2)
+	for i := range hashes {
+		hashes[i] = fmt.Sprintf("cc%04d", i)
+	}
+	hashes[1], hashes[maxBatchSize+1] = "aa01", "aa02"
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, hashes)
+	require.NoError(t, err)
+	require.Len(t, results, len(hashes))
+	require.Len(t, ts.batches, 2)
+	assert.Len(t, ts.batches[0], maxBatchSize)
+	assert.Len(t, ts.batches[1], 2)
+
+	for i, result := range results {
+		switch i {
+		case 1:
+			assert.Equal(t, "banned", result.Status)
+		case maxBatchSize + 1:
+			assert.Equal(t, "flagged", result.Status)
+		default:
+			assert.False(t, result.Found, "hash %d", i)
+		}
+	}
+
+	// No request is made for an empty list
+	results, err = checker.CheckMany(context.Background(), common.CheckTypeUser, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Len(t, ts.batches, 2)
+}
+
+func TestChecker_GetHashCount(t *testing.T) {
+	_, server := newTestServer(t, "")
+	checker, err := New(Config{BaseURL: server.URL + "/api"})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	count, err = checker.GetHashCount(context.Background(), common.CheckTypeGroup)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestChecker_Errors(t *testing.T) {
+	_, authServer := newTestServer(t, "Authorization: Bearer token")
+
+	// A server that has been shut down refuses connections
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		handler http.HandlerFunc
+		wantErr error
+	}{
+		{
+			name:    "Connection refused",
+			cfg:     Config{BaseURL: closed.URL},
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "Timeout",
+			cfg:     Config{BaseURL: slow.URL, Timeout: 50 * time.Millisecond},
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "Wrong credentials",
+			cfg:     Config{BaseURL: authServer.URL + "/api", AuthHeader: "Authorization: Bearer wrong"},
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name: "Server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "database is locked", http.StatusInternalServerError)
+			},
+			wantErr: ErrUnexpectedStatus,
+		},
+		{
+			name: "Malformed response",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("<html>"))
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name: "Missing results",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"results": []}`))
+			},
+			wantErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if tt.handler != nil {
+				server := httptest.NewServer(tt.handler)
+				t.Cleanup(server.Close)
+				cfg.BaseURL = server.URL
+			}
+
+			checker, err := New(cfg)
+			require.NoError(t, err)
+			defer checker.Close()
+
+			result, err := checker.Check(context.Background(), common.CheckTypeUser, "aa01")
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestChecker_Cancelled(t *testing.T) {
+	_, server := newTestServer(t, "")
+	checker, err := New(Config{BaseURL: server.URL + "/api"})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = checker.Check(ctx, common.CheckTypeUser, "aa01")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+}
+
+func TestChecker_Close(t *testing.T) {
+	_, server := newTestServer(t, "")
+	checker, err := New(Config{BaseURL: server.URL + "/api"})
+	require.NoError(t, err)
+	require.NoError(t, checker.Close())
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "aa01")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	require.ErrorIs(t, checker.Each(context.Background(), common.CheckTypeUser, nil), ErrEachNotSupported)
+}
@@ -0,0 +1,260 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Range lookups give clients k-anonymity: instead of a full hash, the client sends its first few hex
+// characters, the server returns every record whose hash starts with them, and the client finds its
+// hash among them locally, so the server never learns which hash was checked:
+//
+//	GET {base}/v1/range/{type}/{prefix}  ->  {"records": [{"hash": "...", "status": "...", ...}]}
+const (
+	// DefaultMinPrefixLength is the shortest prefix a server answers range lookups for when the options
+	// do not set one, which stops clients from downloading the whole export a few requests at a time.
+	DefaultMinPrefixLength = 5
+
+	// maxRequestSize is the largest check request body the server reads.
+	maxRequestSize = 1 << 20
+)
+
+var (
+	ErrInvalidPrefix = errors.New("invalid hash prefix")
+	ErrHashTooShort  = errors.New("hash is shorter than the prefix length")
+)
+
+// Source enumerates the records of an export, such as a checker.Checker.
+type Source interface {
+	Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error
+}
+
+// ServerOptions configures a server.
+type ServerOptions struct {
+	// MinPrefixLength is the shortest prefix answered by range lookups. DefaultMinPrefixLength is used
+	// if it is zero.
+	MinPrefixLength int
+
+	// AuthHeader is an optional header required on every request, in the form "Name: value".
+	AuthHeader string
+
+	// CheckTypes lists the check types loaded from the source. Lookups of the other check types find
+	// nothing. Both user and group records are loaded if it is empty.
+	CheckTypes []common.CheckType
+}
+
+// Server answers the lookups of remote checkers from the records of an export, which are loaded into
+// memory when it is created.
+type Server struct {
+	records         map[common.CheckType][]rangeRecord // Sorted by hash
+	minPrefixLength int
+	authName        string
+	authValue       string
+	mux             *http.ServeMux
+}
+
+// rangeRecord is a record returned by a range lookup.
+type rangeRecord struct {
+	Hash       string  `json:"hash"`
+	Status     string  `json:"status,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// rangeResponse is the body of a successful range response.
+type rangeResponse struct {
+	Records []rangeRecord `json:"records"`
+}
+
+// NewServer loads the user and group records of the source and creates a server that answers lookups
+// of them. Hashes are matched case-insensitively.
+func NewServer(ctx context.Context, src Source, opts ServerOptions) (*Server, error) {
+	if opts.MinPrefixLength < 0 {
+		return nil, fmt.Errorf("%w: minimum prefix length must not be negative", ErrInvalidConfig)
+	}
+	authName, authValue, err := parseAuthHeader(opts.AuthHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		records:         make(map[common.CheckType][]rangeRecord),
+		minPrefixLength: opts.MinPrefixLength,
+		authName:        authName,
+		authValue:       authValue,
+		mux:             http.NewServeMux(),
+	}
+	if s.minPrefixLength == 0 {
+		s.minPrefixLength = DefaultMinPrefixLength
+	}
+
+	checkTypes := opts.CheckTypes
+	if len(checkTypes) == 0 {
+		checkTypes = []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup}
+	}
+
+	// Load the records of each check type, sorted for range lookups
+	for _, checkType := range checkTypes {
+		var records []rangeRecord
+		err := src.Each(ctx, checkType, func(hash string, result *common.CheckResult) error {
+			records = append(records, rangeRecord{
+				Hash:       strings.ToLower(hash),
+				Status:     result.Status,
+				Reason:     result.Reason,
+				Confidence: result.Confidence,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s records: %w", checkType, err)
+		}
+
+		slices.SortFunc(records, func(a, b rangeRecord) int {
+			return strings.Compare(a.Hash, b.Hash)
+		})
+		s.records[checkType] = records
+	}
+
+	s.mux.HandleFunc("GET /v1/range/{type}/{prefix}", s.handleRange)
+	s.mux.HandleFunc("POST /v1/check/{type}", s.handleCheck)
+	s.mux.HandleFunc("GET /v1/count/{type}", s.handleCount)
+
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Compare in constant time so the response time does not reveal how much of the credentials matched
+	if s.authName != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(s.authName)), []byte(s.authValue)) != 1 {
+		http.Error(w, "missing or invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// MinPrefixLength returns the shortest prefix the server answers range lookups for.
+func (s *Server) MinPrefixLength() int {
+	return s.minPrefixLength
+}
+
+// Count returns the number of records of the check type.
+func (s *Server) Count(checkType common.CheckType) uint64 {
+	if checkType != common.CheckTypeGroup {
+		checkType = common.CheckTypeUser
+	}
+	return uint64(len(s.records[checkType]))
+}
+
+// handleRange returns every record whose hash starts with the prefix.
+func (s *Server) handleRange(w http.ResponseWriter, r *http.Request) {
+	records, ok := s.checkTypeRecords(w, r)
+	if !ok {
+		return
+	}
+
+	prefix := strings.ToLower(r.PathValue("prefix"))
+	if err := validatePrefix(prefix); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(prefix) < s.minPrefixLength {
+		http.Error(w, fmt.Sprintf("%v: must be at least %d characters", ErrInvalidPrefix, s.minPrefixLength), http.StatusBadRequest)
+		return
+	}
+
+	// The matching records are next to each other in the sorted records
+	start, _ := slices.BinarySearchFunc(records, prefix, func(r rangeRecord, prefix string) int {
+		return strings.Compare(r.Hash, prefix)
+	})
+	end := start
+	for end < len(records) && strings.HasPrefix(records[end].Hash, prefix) {
+		end++
+	}
+
+	writeJSON(w, rangeResponse{Records: append([]rangeRecord{}, records[start:end]...)})
+}
+
+// handleCheck returns the result of every hash in the request.
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	records, ok := s.checkTypeRecords(w, r)
+	if !ok {
+		return
+	}
+
+	var req checkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Hashes) > maxBatchSize {
+		http.Error(w, fmt.Sprintf("invalid request: more than %d hashes", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]*common.CheckResult, len(req.Hashes))
+	for i, hash := range req.Hashes {
+		results[i] = &common.CheckResult{}
+		index, found := slices.BinarySearchFunc(records, strings.ToLower(hash), func(r rangeRecord, hash string) int {
+			return strings.Compare(r.Hash, hash)
+		})
+		if found {
+			record := records[index]
+			results[i] = &common.CheckResult{
+				Found:      true,
+				Status:     record.Status,
+				Reason:     record.Reason,
+				Confidence: record.Confidence,
+			}
+		}
+	}
+
+	writeJSON(w, checkResponse{Results: results})
+}
+
+// handleCount returns the number of records of the check type.
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	records, ok := s.checkTypeRecords(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, countResponse{Count: uint64(len(records))})
+}
+
+// checkTypeRecords returns the records of the check type in the request path, which are empty if the
+// check type was not loaded. If the check type is unknown, a not found response is written and ok is false.
+func (s *Server) checkTypeRecords(w http.ResponseWriter, r *http.Request) ([]rangeRecord, bool) {
+	switch checkType := common.CheckType(r.PathValue("type")); checkType {
+	case common.CheckTypeUser, common.CheckTypeGroup:
+		return s.records[checkType], true
+	default:
+		http.NotFound(w, r)
+		return nil, false
+	}
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// validatePrefix checks that the prefix only contains lowercase hex characters.
+func validatePrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidPrefix)
+	}
+	for _, c := range prefix {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("%w: %q is not hexadecimal", ErrInvalidPrefix, prefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource enumerates fixed records for each check type.
+type fakeSource struct {
+	records map[common.CheckType]map[string]*common.CheckResult
+	err     error
+}
+
+func (s *fakeSource) Each(_ context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	if s.err != nil {
+		return s.err
+	}
+	for hash, result := range s.records[checkType] {
+		if err := fn(hash, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		records: map[common.CheckType]map[string]*common.CheckResult{
+			common.CheckTypeUser: {
+				"abcde01": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+				"ABCDE02": {Found: true, Status: "flagged", Reason: "suspicious", Confidence: 0.5},
+				"abcdf01": {Found: true, Status: "flagged", Reason: "other", Confidence: 0.7},
+			},
+			common.CheckTypeGroup: {
+				"12345ff": {Found: true, Status: "locked", Reason: "scam", Confidence: 0.8},
+			},
+		},
+	}
+}
+
+// startServer serves the source and records the path of every request.
+func startServer(t *testing.T, src Source, opts ServerOptions) (*httptest.Server, func() []string) {
+	t.Helper()
+	server, err := NewServer(context.Background(), src, opts)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, paths...)
+	}
+}
+
+func TestServer_RangeLookups(t *testing.T) {
+	ts, paths := startServer(t, newFakeSource(), ServerOptions{})
+	checker, err := New(Config{BaseURL: ts.URL, PrefixLength: DefaultMinPrefixLength})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	hashes := []string{"abcde01", "abcde02", "abcde03", "abcdf01", "fffff00", "abcde01"}
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, hashes)
+	require.NoError(t, err)
+	require.Len(t, results, len(hashes))
+
+	assert.Equal(t, &common.CheckResult{Found: true, Status: "banned", Reason: "violation", Confidence: 0.95}, results[0])
+	assert.Equal(t, "flagged", results[1].Status, "hashes are matched case-insensitively")
+	assert.False(t, results[2].Found)
+	assert.Equal(t, "other", results[3].Reason)
+	assert.False(t, results[4].Found)
+	assert.Equal(t, results[0], results[5])
+
+	// Only the prefixes are sent, once each
+	assert.Equal(t, []string{"/v1/range/user/abcde", "/v1/range/user/abcdf", "/v1/range/user/fffff"}, paths())
+
+	result, err := checker.Check(context.Background(), common.CheckTypeGroup, "12345FF")
+	require.NoError(t, err)
+	assert.Equal(t, "locked", result.Status)
+
+	// Hashes must be at least as long as the prefix
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "abc")
+	require.ErrorIs(t, err, ErrHashTooShort)
+}
+
+func TestServer_FullHashLookups(t *testing.T) {
+	ts, _ := startServer(t, newFakeSource(), ServerOptions{AuthHeader: "Authorization: Bearer token"})
+	checker, err := New(Config{BaseURL: ts.URL, AuthHeader: "Authorization: Bearer token"})
+	require.NoError(t, err)
+	defer checker.Close()
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"abcde02", "abcde03"})
+	require.NoError(t, err)
+	assert.Equal(t, []*common.CheckResult{
+		{Found: true, Status: "flagged", Reason: "suspicious", Confidence: 0.5},
+		{},
+	}, results)
+
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+
+	// Requests without the auth header are refused
+	unauthorized, err := New(Config{BaseURL: ts.URL, PrefixLength: 5})
+	require.NoError(t, err)
+	defer unauthorized.Close()
+	_, err = unauthorized.Check(context.Background(), common.CheckTypeUser, "abcde01")
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestServer_InvalidRequests(t *testing.T) {
+	ts, _ := startServer(t, newFakeSource(), ServerOptions{MinPrefixLength: 4})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "Prefix shorter than the minimum",
+			method:     http.MethodGet,
+			path:       "/v1/range/user/abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Prefix at the minimum",
+			method:     http.MethodGet,
+			path:       "/v1/range/user/abcd",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Prefix that is not hexadecimal",
+			method:     http.MethodGet,
+			path:       "/v1/range/user/abcz",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown check type",
+			method:     http.MethodGet,
+			path:       "/v1/range/friends/abcd",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Malformed check request",
+			method:     http.MethodPost,
+			path:       "/v1/check/user",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Check request with too many hashes",
+			method:     http.MethodPost,
+			path:       "/v1/check/user",
+			body:       `{"hashes": ["` + strings.Repeat(`a", "`, maxBatchSize) + `a"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Wrong method",
+			method:     http.MethodPost,
+			path:       "/v1/count/user",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+
+	// A client with a shorter prefix than the server allows gets a clear error
+	checker, err := New(Config{BaseURL: ts.URL, PrefixLength: 2})
+	require.NoError(t, err)
+	defer checker.Close()
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "abcde01")
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Contains(t, err.Error(), "must be at least 4 characters")
+}
+
+func TestNewServer_Errors(t *testing.T) {
+	errSource := errors.New("export is corrupt")
+	_, err := NewServer(context.Background(), &fakeSource{err: errSource}, ServerOptions{})
+	require.ErrorIs(t, err, errSource)
+
+	_, err = NewServer(context.Background(), newFakeSource(), ServerOptions{MinPrefixLength: -1})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewServer(context.Background(), newFakeSource(), ServerOptions{AuthHeader: "invalid"})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	server, err := NewServer(context.Background(), newFakeSource(), ServerOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMinPrefixLength, server.MinPrefixLength())
+}
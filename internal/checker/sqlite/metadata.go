@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// SupportedSchemaVersion is the newest export schema version this checker can read.
+const SupportedSchemaVersion = 1
+
+var (
+	ErrInvalidMetadata          = errors.New("invalid export metadata")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported export schema version")
+	ErrUnindexedHash            = errors.New("hash column has no index or unique constraint")
+	ErrMetadataMismatch         = errors.New("metadata does not match the table")
+)
+
+// Metadata describes an export database. It is read from the optional metadata table, which holds
+// key-value pairs:
+//
+//	CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+//
+// with the keys schema_version (integer), record_count (integer) and created_at (RFC 3339 timestamp).
+type Metadata struct {
+	SchemaVersion int
+	RecordCount   uint64
+	CreatedAt     time.Time
+}
+
+// readMetadata reads the metadata table of the database, returning nil if the database has none.
+func readMetadata(conn *sqlite.Conn) (*Metadata, error) {
+	exists := false
+	err := sqlitex.Execute(conn, "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'metadata'",
+		&sqlitex.ExecOptions{
+			ResultFunc: func(_ *sqlite.Stmt) error {
+				exists = true
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if !exists {
+		return nil, nil //nolint:nilnil
+	}
+
+	values := make(map[string]string)
+	err = sqlitex.Execute(conn, "SELECT key, value FROM metadata", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			values[stmt.ColumnText(0)] = stmt.ColumnText(1)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMetadata, err)
+	}
+
+	return parseMetadata(values)
+}
+
+// parseMetadata parses the values of the metadata table. Unknown keys are ignored.
+func parseMetadata(values map[string]string) (*Metadata, error) {
+	for _, key := range []string{"schema_version", "record_count", "created_at"} {
+		if _, ok := values[key]; !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidMetadata, key)
+		}
+	}
+
+	version, err := strconv.Atoi(values["schema_version"])
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("%w: schema_version %q is not a positive integer", ErrInvalidMetadata, values["schema_version"])
+	}
+	if version > SupportedSchemaVersion {
+		return nil, fmt.Errorf("%w: %d, this version of Rotten supports up to %d",
+			ErrUnsupportedSchemaVersion, version, SupportedSchemaVersion)
+	}
+
+	count, err := strconv.ParseUint(values["record_count"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: record_count %q is not a count", ErrInvalidMetadata, values["record_count"])
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, values["created_at"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: created_at %q is not an RFC 3339 timestamp", ErrInvalidMetadata, values["created_at"])
+	}
+
+	return &Metadata{
+		SchemaVersion: version,
+		RecordCount:   count,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// hasHashIndex reports whether the table has an index, primary key or unique constraint
+// whose first column is hash, so lookups by hash do not scan the whole table.
+func hasHashIndex(conn *sqlite.Conn, tableName string) (bool, error) {
+	indexed := false
+	err := sqlitex.Execute(conn,
+		"SELECT 1 FROM pragma_index_list(?) AS list, pragma_index_info(list.name) AS info "+
+			"WHERE info.seqno = 0 AND info.name = 'hash' LIMIT 1",
+		&sqlitex.ExecOptions{
+			Args: []any{tableName},
+			ResultFunc: func(_ *sqlite.Stmt) error {
+				indexed = true
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	return indexed, nil
+}
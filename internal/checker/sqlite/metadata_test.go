@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// writeDatabase creates users.db with the given table definition, one record and the given metadata.
+// No metadata table is created if metadata is nil.
+func writeDatabase(t *testing.T, dir, table string, metadata map[string]string) {
+	t.Helper()
+
+	conn, err := sqlite.OpenConn(filepath.Join(dir, "users.db"), sqlite.OpenCreate|sqlite.OpenReadWrite)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, sqlitex.ExecScript(conn, table+`;
+		INSERT INTO users (hash, status, reason, confidence) VALUES ('testHash123', 'banned', 'violation', 0.95);`))
+
+	if metadata == nil {
+		return
+	}
+	require.NoError(t, sqlitex.ExecScript(conn, "CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);"))
+	for key, value := range metadata {
+		require.NoError(t, sqlitex.Execute(conn, "INSERT INTO metadata VALUES (?, ?)",
+			&sqlitex.ExecOptions{Args: []any{key, value}}))
+	}
+}
+
+const (
+	indexedTable   = "CREATE TABLE users (hash TEXT PRIMARY KEY, status TEXT, reason TEXT, confidence REAL)"
+	unindexedTable = "CREATE TABLE users (hash TEXT, status TEXT, reason TEXT, confidence REAL)"
+)
+
+func TestChecker_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	writeDatabase(t, dir, indexedTable, map[string]string{
+		"schema_version": "1",
+		"record_count":   "42",
+		"created_at":     "2025-01-02T03:04:05Z",
+		"generator":      "rotector", // Unknown keys are ignored
+	})
+
+	checker := New(dir)
+	defer checker.Close()
+
+	metadata, err := checker.Metadata(common.CheckTypeUser)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, 1, metadata.SchemaVersion)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), metadata.CreatedAt)
+
+	// The count comes from the metadata rather than the table
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), count)
+	assert.Empty(t, checker.Warnings())
+
+	// Walk reports the count that does not match the table
+	problems, err := Walk(dir, common.CheckTypeUser, func(string, *common.CheckResult) error { return nil })
+	require.NoError(t, err)
+	require.NotEmpty(t, problems)
+	assert.ErrorIs(t, problems[len(problems)-1], ErrMetadataMismatch)
+
+	// Exports without metadata count the rows
+	dir = t.TempDir()
+	writeDatabase(t, dir, indexedTable, nil)
+	checker = New(dir)
+	defer checker.Close()
+
+	metadata, err = checker.Metadata(common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Nil(t, metadata)
+	count, err = checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestChecker_InvalidMetadata(t *testing.T) {
+	valid := map[string]string{"schema_version": "1", "record_count": "1", "created_at": "2025-01-02T03:04:05Z"}
+	with := func(key, value string) map[string]string {
+		metadata := map[string]string{}
+		for k, v := range valid {
+			metadata[k] = v
+		}
+		if value == "" {
+			delete(metadata, key)
+		} else {
+			metadata[key] = value
+		}
+		return metadata
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  error
+	}{
+		{name: "Missing key", metadata: with("created_at", ""), wantErr: ErrInvalidMetadata},
+		{name: "Invalid version", metadata: with("schema_version", "one"), wantErr: ErrInvalidMetadata},
+		{name: "Newer version", metadata: with("schema_version", "2"), wantErr: ErrUnsupportedSchemaVersion},
+		{name: "Invalid count", metadata: with("record_count", "-1"), wantErr: ErrInvalidMetadata},
+		{name: "Invalid timestamp", metadata: with("created_at", "yesterday"), wantErr: ErrInvalidMetadata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDatabase(t, dir, indexedTable, tt.metadata)
+
+			_, err := New(dir).Check(context.Background(), common.CheckTypeUser, "testHash123")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChecker_UnindexedHash(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		wantIndexed bool
+	}{
+		{name: "Primary key", table: indexedTable, wantIndexed: true},
+		{name: "Unique constraint", table: "CREATE TABLE users (hash TEXT UNIQUE, status TEXT, reason TEXT, confidence REAL)", wantIndexed: true},
+		{name: "Index", table: unindexedTable + "; CREATE INDEX users_hash ON users (hash, status)", wantIndexed: true},
+		{name: "Index on another column", table: unindexedTable + "; CREATE INDEX users_status ON users (status, hash)"},
+		{name: "No index", table: unindexedTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDatabase(t, dir, tt.table, nil)
+
+			// Lookups still work, with a warning
+			checker := New(dir)
+			defer checker.Close()
+			result, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+			require.NoError(t, err)
+			assert.True(t, result.Found)
+
+			strict := NewWithOptions(dir, Options{Strict: true})
+			defer strict.Close()
+			_, err = strict.Check(context.Background(), common.CheckTypeUser, "testHash123")
+
+			indexed, indexedErr := checker.Indexed(common.CheckTypeUser)
+			require.NoError(t, indexedErr)
+			assert.Equal(t, tt.wantIndexed, indexed)
+
+			if tt.wantIndexed {
+				assert.Empty(t, checker.Warnings())
+				assert.NoError(t, err)
+				return
+			}
+			require.Len(t, checker.Warnings(), 1)
+			assert.ErrorIs(t, checker.Warnings()[0], ErrUnindexedHash)
+			assert.Contains(t, checker.Warnings()[0].Error(), "users.db")
+			assert.ErrorIs(t, err, ErrUnindexedHash)
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package sqlite
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sync"
 
 	"github.com/robalyx/rotten/internal/common"
 	"zombiezen.com/go/sqlite"
@@ -12,42 +15,134 @@ import (
 
 var ErrInvalidSchema = errors.New("invalid database schema")
 
+// database is an open export database for a single check type.
+type database struct {
+	conn      *sqlite.Conn
+	tableName string
+	metadata  *Metadata // Nil if the database has no metadata table
+	indexed   bool      // Set if lookups by hash use an index
+	err       error     // Set if the database could not be opened
+}
+
+// Options configures how the databases are opened.
+type Options struct {
+	// Strict refuses databases whose hash column is not indexed instead of reporting them as warnings.
+	Strict bool
+}
+
 // Checker implements the common.Checker interface for SQLite storage.
+// The databases are opened once and shared, so lookups are serialized.
 type Checker struct {
-	dir string
+	dir    string
+	mu     sync.Mutex
+	users  *database
+	groups *database
 }
 
-// New creates a new SQLite checker.
+// New creates a new SQLite checker and opens the user and group databases.
+// A database that cannot be opened returns its error on every lookup.
 func New(dir string) *Checker {
-	return &Checker{dir: dir}
+	return NewWithOptions(dir, Options{})
 }
 
-// Check verifies if the given ID exists in the SQLite database.
-func (c *Checker) Check(checkType common.CheckType, id string) (*common.CheckResult, error) {
-	// Determine filename based on check type
-	filename := "users.db"
-	tableName := "users"
-	if checkType == common.CheckTypeGroup {
-		filename = "groups.db"
-		tableName = "groups"
+// NewWithOptions creates a new SQLite checker and opens the user and group databases using opts.
+func NewWithOptions(dir string, opts Options) *Checker {
+	return &Checker{
+		dir:    dir,
+		users:  openDatabase(filepath.Join(dir, "users.db"), "users", opts),
+		groups: openDatabase(filepath.Join(dir, "groups.db"), "groups", opts),
 	}
+}
 
-	// Open database
-	dbPath := filepath.Join(c.dir, filename)
-	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+// openDatabase opens a database read-only, validates its schema and reads its metadata.
+func openDatabase(path, tableName string, opts Options) *database {
+	db := &database{tableName: tableName}
+
+	conn, err := sqlite.OpenConn(path, sqlite.OpenReadOnly)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		db.err = fmt.Errorf("failed to open database: %w", err)
+		return db
 	}
-	defer conn.Close()
 
 	// Validate schema
 	if err := validateSchema(conn, tableName); err != nil {
-		return nil, err
+		conn.Close()
+		db.err = err
+		return db
+	}
+
+	// Read metadata
+	if db.metadata, err = readMetadata(conn); err != nil {
+		conn.Close()
+		db.err = err
+		return db
+	}
+
+	// Check that lookups can use an index
+	if db.indexed, err = hasHashIndex(conn, tableName); err != nil {
+		conn.Close()
+		db.err = err
+		return db
 	}
+	if !db.indexed && opts.Strict {
+		conn.Close()
+		db.err = fmt.Errorf("%w in %s.db", ErrUnindexedHash, tableName)
+		return db
+	}
+
+	db.conn = conn
+	return db
+}
+
+// database returns the open database for the check type.
+func (c *Checker) database(checkType common.CheckType) (*database, error) {
+	db := c.users
+	if checkType == common.CheckTypeGroup {
+		db = c.groups
+	}
+	if db.err != nil {
+		return nil, db.err
+	}
+	if db.conn == nil {
+		return nil, common.ErrCheckerClosed
+	}
+	return db, nil
+}
+
+// execute runs a query on the database of the check type, interrupting it when the context is done.
+// Query errors are wrapped with msg.
+func (c *Checker) execute(
+	ctx context.Context, checkType common.CheckType, msg string, query func(tableName string) string, opts *sqlitex.ExecOptions,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	db, err := c.database(checkType)
+	if err != nil {
+		return err
+	}
+
+	// Interrupt queries when the context is done
+	db.conn.SetInterrupt(ctx.Done())
+	defer db.conn.SetInterrupt(nil)
+
+	if err := sqlitex.Execute(db.conn, query(db.tableName), opts); err != nil {
+		return queryError(ctx, msg, err)
+	}
+	return nil
+}
+
+// Check verifies if the given ID exists in the SQLite database.
+func (c *Checker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
 	var result common.CheckResult
-	query := fmt.Sprintf("SELECT status, reason, confidence FROM %s WHERE hash = ?", tableName)
-	err = sqlitex.Execute(conn, query,
+	err := c.execute(ctx, checkType, "failed to query database",
+		func(tableName string) string {
+			return fmt.Sprintf("SELECT status, reason, confidence FROM %s WHERE hash = ?", tableName)
+		},
 		&sqlitex.ExecOptions{
 			Args: []interface{}{id},
 			ResultFunc: func(stmt *sqlite.Stmt) error {
@@ -62,38 +157,82 @@ func (c *Checker) Check(checkType common.CheckType, id string) (*common.CheckRes
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query database: %w", err)
+		return nil, err
 	}
 
 	return &result, nil
 }
 
-// GetHashCount returns the number of hashes in the database.
-func (c *Checker) GetHashCount(checkType common.CheckType) (uint64, error) {
-	// Determine filename based on check type
-	filename := "users.db"
-	tableName := "users"
-	if checkType == common.CheckTypeGroup {
-		filename = "groups.db"
-		tableName = "groups"
+// CheckMany verifies which of the given hashes exist in the SQLite database using a single query.
+// The results are in the same order as the hashes.
+func (c *Checker) CheckMany(ctx context.Context, checkType common.CheckType, hashes []string) ([]*common.CheckResult, error) {
+	// Map each hash to its positions in the results
+	results := make([]*common.CheckResult, len(hashes))
+	positions := make(map[string][]int, len(hashes))
+	for i, hash := range hashes {
+		results[i] = &common.CheckResult{}
+		positions[hash] = append(positions[hash], i)
+	}
+	if len(hashes) == 0 {
+		return results, nil
 	}
 
-	// Open database
-	dbPath := filepath.Join(c.dir, filename)
-	conn, err := sqlite.OpenConn(dbPath, sqlite.OpenReadOnly)
+	// Pass the hashes as a JSON array to avoid the limit on the number of query parameters
+	hashesJSON, err := json.Marshal(hashes)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open database: %w", err)
+		return nil, fmt.Errorf("failed to encode hashes: %w", err)
 	}
-	defer conn.Close()
 
-	// Validate schema
-	if err := validateSchema(conn, tableName); err != nil {
+	err = c.execute(ctx, checkType, "failed to query database",
+		func(tableName string) string {
+			return fmt.Sprintf("SELECT hash, status, reason, confidence FROM %s "+
+				"WHERE hash IN (SELECT value FROM json_each(?))", tableName)
+		},
+		&sqlitex.ExecOptions{
+			Args: []interface{}{string(hashesJSON)},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				result := &common.CheckResult{
+					Found:      true,
+					Status:     stmt.ColumnText(1),
+					Reason:     stmt.ColumnText(2),
+					Confidence: stmt.ColumnFloat(3),
+				}
+				for _, i := range positions[stmt.ColumnText(0)] {
+					results[i] = result
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetHashCount returns the number of hashes in the database.
+// The count is read from the metadata table if the database has one.
+func (c *Checker) GetHashCount(ctx context.Context, checkType common.CheckType) (uint64, error) {
+	if err := ctx.Err(); err != nil {
 		return 0, err
 	}
 
+	c.mu.Lock()
+	db, err := c.database(checkType)
+	c.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if db.metadata != nil {
+		return db.metadata.RecordCount, nil
+	}
+
 	var count uint64
-	query := "SELECT COUNT(*) FROM " + tableName
-	err = sqlitex.Execute(conn, query,
+	err = c.execute(ctx, checkType, "failed to count hashes",
+		func(tableName string) string {
+			return "SELECT COUNT(*) FROM " + tableName
+		},
 		&sqlitex.ExecOptions{
 			ResultFunc: func(stmt *sqlite.Stmt) error {
 				count = uint64(stmt.ColumnInt64(0)) //nolint:gosec
@@ -102,12 +241,102 @@ func (c *Checker) GetHashCount(checkType common.CheckType) (uint64, error) {
 		},
 	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count hashes: %w", err)
+		return 0, err
 	}
 
 	return count, nil
 }
 
+// Each calls fn with every record in the database, stopping at the first error.
+func (c *Checker) Each(ctx context.Context, checkType common.CheckType, fn common.RecordFunc) error {
+	var fnErr error
+	err := c.execute(ctx, checkType, "failed to read database",
+		func(tableName string) string {
+			return "SELECT hash, status, reason, confidence FROM " + tableName
+		},
+		&sqlitex.ExecOptions{
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				fnErr = fn(stmt.ColumnText(0), &common.CheckResult{
+					Found:      true,
+					Status:     stmt.ColumnText(1),
+					Reason:     stmt.ColumnText(2),
+					Confidence: stmt.ColumnFloat(3),
+				})
+				return fnErr
+			},
+		},
+	)
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// Metadata returns the metadata of the database for the check type, or nil if it has none.
+func (c *Checker) Metadata(checkType common.CheckType) (*Metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	db, err := c.database(checkType)
+	if err != nil {
+		return nil, err
+	}
+	return db.metadata, nil
+}
+
+// Indexed reports whether lookups in the database for the check type use an index on the hash column.
+func (c *Checker) Indexed(checkType common.CheckType) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	db, err := c.database(checkType)
+	if err != nil {
+		return false, err
+	}
+	return db.indexed, nil
+}
+
+// Warnings returns the problems of the open databases that slow down lookups without preventing them.
+func (c *Checker) Warnings() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var warnings []error
+	for _, db := range []*database{c.users, c.groups} {
+		if db.conn != nil && !db.indexed {
+			warnings = append(warnings, fmt.Errorf("%w in %s.db, every lookup scans the whole table", ErrUnindexedHash, db.tableName))
+		}
+	}
+	return warnings
+}
+
+// Close closes the databases. Lookups after Close return common.ErrCheckerClosed.
+func (c *Checker) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, db := range []*database{c.users, c.groups} {
+		if db.conn == nil {
+			continue
+		}
+		if err := db.conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s database: %w", db.tableName, err))
+		}
+		db.conn = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// queryError wraps the error of a failed query, reporting the context error if the query was interrupted.
+func queryError(ctx context.Context, msg string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%s: %w", msg, ctxErr)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
 // validateSchema checks if the table has the required columns.
 func validateSchema(conn *sqlite.Conn, tableName string) error {
 	err := sqlitex.Execute(conn, "SELECT hash, status, reason, confidence FROM "+tableName+" LIMIT 0",
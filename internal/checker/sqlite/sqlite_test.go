@@ -1,6 +1,8 @@
 package sqlite
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -96,7 +98,7 @@ func TestChecker_Check(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			result, err := checker.Check(tt.checkType, tt.hash)
+			result, err := checker.Check(context.Background(), tt.checkType, tt.hash)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -147,7 +149,7 @@ func TestChecker_GetHashCount(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := New(tempDir)
-			count, err := checker.GetHashCount(tt.checkType)
+			count, err := checker.GetHashCount(context.Background(), tt.checkType)
 
 			if tt.wantErrType != nil {
 				assert.ErrorIs(t, err, tt.wantErrType)
@@ -164,7 +166,7 @@ func TestChecker_NonexistentFile(t *testing.T) {
 	tempDir := t.TempDir()
 	checker := New(tempDir)
 
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
@@ -179,12 +181,12 @@ func TestChecker_InvalidDatabase(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with invalid database
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with invalid database
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -209,12 +211,12 @@ func TestChecker_InvalidSchema(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with invalid schema
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.Error(t, err)
 	assert.Nil(t, result)
 
 	// Test GetHashCount with invalid schema
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.Error(t, err)
 	assert.Zero(t, count)
 }
@@ -241,14 +243,92 @@ func TestChecker_EmptyDatabase(t *testing.T) {
 	checker := New(tempDir)
 
 	// Test Check with empty database
-	result, err := checker.Check(common.CheckTypeUser, "0123456789abcdef")
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "0123456789abcdef")
 	assert.NoError(t, err)
 	assert.False(t, result.Found)
 	assert.Empty(t, result.Status)
 	assert.Empty(t, result.Reason)
 
 	// Test GetHashCount with empty database
-	count, err := checker.GetHashCount(common.CheckTypeUser)
+	count, err := checker.GetHashCount(context.Background(), common.CheckTypeUser)
 	assert.NoError(t, err)
 	assert.Zero(t, count)
 }
+
+func TestChecker_CheckMany(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+	checker := New(tempDir)
+
+	results, err := checker.CheckMany(context.Background(), common.CheckTypeUser, []string{"testHash123", "0123456789abcdef", "testHash123"})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[1].Found)
+	for _, result := range []*common.CheckResult{results[0], results[2]} {
+		assert.True(t, result.Found)
+		assert.Equal(t, "banned", result.Status)
+		assert.Equal(t, "violation", result.Reason)
+		assert.InDelta(t, 0.95, result.Confidence, 0.001)
+	}
+
+	// Large batches are not limited by the number of query parameters
+	hashes := make([]string, 40000)
+	for i := range hashes {
+		hashes[i] = "missing"
+	}
+	hashes[len(hashes)-1] = "testHash123"
+	results, err = checker.CheckMany(context.Background(), common.CheckTypeUser, hashes)
+	require.NoError(t, err)
+	assert.False(t, results[0].Found)
+	assert.True(t, results[len(results)-1].Found)
+
+	_, err = New(t.TempDir()).CheckMany(context.Background(), common.CheckTypeUser, []string{"testHash123"})
+	assert.Error(t, err)
+}
+
+func TestChecker_Close(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	checker := New(tempDir)
+	_, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.NoError(t, err)
+
+	require.NoError(t, checker.Close())
+	require.NoError(t, checker.Close(), "Close should be idempotent")
+
+	_, err = checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.CheckMany(context.Background(), common.CheckTypeGroup, []string{"testHash123"})
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+	_, err = checker.GetHashCount(context.Background(), common.CheckTypeGroup)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+}
+
+func TestChecker_Each(t *testing.T) {
+	tempDir := t.TempDir()
+	setupTestFiles(t, tempDir)
+
+	checker := New(tempDir)
+	defer checker.Close()
+
+	results := make(map[string]common.CheckResult)
+	err := checker.Each(context.Background(), common.CheckTypeUser, func(hash string, result *common.CheckResult) error {
+		results[hash] = *result
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]common.CheckResult{
+		"testHash123": {Found: true, Status: "banned", Reason: "violation", Confidence: 0.95},
+	}, results)
+
+	// The error of fn is returned as is
+	errStop := errors.New("stop")
+	err = checker.Each(context.Background(), common.CheckTypeUser, func(string, *common.CheckResult) error { return errStop })
+	assert.Equal(t, errStop, err)
+
+	// Lookups still work after the enumeration is stopped
+	result, err := checker.Check(context.Background(), common.CheckTypeUser, "testHash123")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// Walk fully reads the database for the check type, calling fn with the hash of every valid record.
+// Integrity check failures, corrupt rows, rows rejected by fn and metadata that does not match the
+// table are returned as problems, with rows identified by their rowid.
+// An error is returned if the database cannot be read at all, or wraps common.ErrStopWalk if fn stopped the walk.
+func Walk(dir string, checkType common.CheckType, fn common.RecordFunc) ([]error, error) {
+	// Determine filename based on check type
+	filename := "users.db"
+	tableName := "users"
+	if checkType == common.CheckTypeGroup {
+		filename = "groups.db"
+		tableName = "groups"
+	}
+
+	// Open database
+	conn, err := sqlite.OpenConn(filepath.Join(dir, filename), sqlite.OpenReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	// Validate schema
+	if err := validateSchema(conn, tableName); err != nil {
+		return nil, err
+	}
+
+	var problems []error
+
+	// Check database structure
+	err = sqlitex.Execute(conn, "PRAGMA integrity_check", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			if result := stmt.ColumnText(0); result != "ok" {
+				problems = append(problems, fmt.Errorf("integrity check: %s", result))
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+
+	// Check every row
+	var rows uint64
+	query := "SELECT rowid, hash, typeof(confidence), confidence, status, reason FROM " + tableName
+	err = sqlitex.Execute(conn, query, &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			rows++
+			rowID := stmt.ColumnInt64(0)
+			hash := stmt.ColumnText(1)
+
+			if confidenceType := stmt.ColumnText(2); confidenceType != "real" && confidenceType != "integer" {
+				problems = append(problems, fmt.Errorf("row %d: confidence has type %s", rowID, confidenceType))
+				return nil
+			}
+			if err := common.ValidateRecord(hash, stmt.ColumnFloat(3)); err != nil {
+				problems = append(problems, fmt.Errorf("row %d: %w", rowID, err))
+				return nil
+			}
+
+			result := &common.CheckResult{
+				Found:      true,
+				Status:     stmt.ColumnText(4),
+				Reason:     stmt.ColumnText(5),
+				Confidence: stmt.ColumnFloat(3),
+			}
+			err := fn(hash, result)
+			if errors.Is(err, common.ErrStopWalk) {
+				return err
+			}
+			if err != nil {
+				problems = append(problems, fmt.Errorf("row %d: %w", rowID, err))
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return problems, fmt.Errorf("failed to read database: %w", err)
+	}
+
+	// Check that the metadata describes the table, since lookups trust its record count
+	metadata, err := readMetadata(conn)
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Errorf("metadata: %w", err))
+	case metadata != nil && metadata.RecordCount != rows:
+		problems = append(problems, fmt.Errorf("%w: record_count is %d, table has %d rows",
+			ErrMetadataMismatch, metadata.RecordCount, rows))
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// ConfidenceBuckets is the number of equal-width buckets in the confidence histogram.
+const ConfidenceBuckets = 10
+
+// Count is the number of records with a given value.
+type Count struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Stats summarizes the records of a storage file.
+type Stats struct {
+	CheckType   common.CheckType
+	StorageType common.StorageType
+	Total       int                    // Number of valid records
+	Skipped     int                    // Number of corrupt records that were not counted
+	Confidence  [ConfidenceBuckets]int // Record counts by confidence, from 0 to 1
+
+	statuses map[string]int
+	reasons  map[string]int
+}
+
+// CollectStats reads every record of the storage file for the check type and summarizes it.
+func CollectStats(dir string, checkType common.CheckType, storageType common.StorageType) (*Stats, error) {
+	stats := &Stats{
+		CheckType:   checkType,
+		StorageType: storageType,
+		statuses:    make(map[string]int),
+		reasons:     make(map[string]int),
+	}
+
+	problems, err := walkFile(dir, checkType, storageType, func(_ string, result *common.CheckResult) error {
+		stats.add(result)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s records: %w", storageType, checkType, err)
+	}
+	stats.Skipped = len(problems)
+
+	return stats, nil
+}
+
+// add counts a single record.
+func (s *Stats) add(result *common.CheckResult) {
+	s.Total++
+	s.statuses[result.Status]++
+
+	// Confidence of exactly 1 belongs in the last bucket
+	bucket := min(int(result.Confidence*ConfidenceBuckets), ConfidenceBuckets-1)
+	s.Confidence[bucket]++
+
+	// Reasons are made of fragments separated by "; "
+	for _, fragment := range strings.Split(result.Reason, "; ") {
+		if fragment = strings.TrimSpace(fragment); fragment != "" {
+			s.reasons[fragment]++
+		}
+	}
+}
+
+// Statuses returns the record count of each status, most frequent first.
+func (s *Stats) Statuses() []Count {
+	return sortCounts(s.statuses)
+}
+
+// TopReasons returns up to n of the most frequent reason fragments.
+func (s *Stats) TopReasons(n int) []Count {
+	counts := sortCounts(s.reasons)
+	return counts[:min(n, len(counts))]
+}
+
+// BucketLabel returns the confidence range covered by a histogram bucket.
+func BucketLabel(bucket int) string {
+	return fmt.Sprintf("%.1f-%.1f", float64(bucket)/ConfidenceBuckets, float64(bucket+1)/ConfidenceBuckets)
+}
+
+// sortCounts converts a count map to a slice sorted by count, then by value.
+func sortCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for value, count := range m {
+		counts = append(counts, Count{Value: value, Count: count})
+	}
+
+	slices.SortFunc(counts, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+
+	return counts
+}
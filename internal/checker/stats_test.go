@@ -0,0 +1,43 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectStats(t *testing.T) {
+	dir := t.TempDir()
+	writeExport(t, dir, "users", []testRecord{
+		{hash: testHash(1), status: "confirmed", reason: "bad name; bad avatar", confidence: 1},
+		{hash: testHash(2), status: "confirmed", reason: "bad name", confidence: 0.95},
+		{hash: testHash(3), status: "flagged", reason: "bad avatar; bad name", confidence: 0.42},
+		{hash: testHash(4), status: "flagged", reason: "bad description", confidence: 0},
+	})
+
+	for _, storageType := range []common.StorageType{
+		common.StorageTypeSQLite, common.StorageTypeBinary, common.StorageTypeCSV,
+	} {
+		t.Run(string(storageType), func(t *testing.T) {
+			stats, err := CollectStats(dir, common.CheckTypeUser, storageType)
+			require.NoError(t, err)
+
+			assert.Equal(t, 4, stats.Total)
+			assert.Zero(t, stats.Skipped)
+			assert.Equal(t, []Count{{"confirmed", 2}, {"flagged", 2}}, stats.Statuses())
+			assert.Equal(t, [ConfidenceBuckets]int{1, 0, 0, 0, 1, 0, 0, 0, 0, 2}, stats.Confidence)
+			assert.Equal(t, []Count{{"bad name", 3}, {"bad avatar", 2}}, stats.TopReasons(2))
+			assert.Len(t, stats.TopReasons(10), 3)
+		})
+	}
+
+	_, err := CollectStats(dir, common.CheckTypeGroup, common.StorageTypeCSV)
+	assert.Error(t, err)
+}
+
+func TestBucketLabel(t *testing.T) {
+	assert.Equal(t, "0.0-0.1", BucketLabel(0))
+	assert.Equal(t, "0.9-1.0", BucketLabel(ConfidenceBuckets-1))
+}
@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/checker/cdb"
+	"github.com/robalyx/rotten/internal/checker/csv"
+	"github.com/robalyx/rotten/internal/checker/jsonl"
 	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
 )
 
 const (
@@ -19,22 +25,27 @@ var ErrMissingFile = errors.New("missing required file")
 
 // Validator handles validation of export directories and files.
 type Validator struct {
-	requiredFiles map[common.CheckType]map[common.StorageType]string
+	// requiredFiles lists the names each storage file may have, in order of preference
+	requiredFiles map[common.CheckType]map[common.StorageType][]string
 }
 
 // NewValidator creates a new Validator instance.
 func NewValidator() *Validator {
 	return &Validator{
-		requiredFiles: map[common.CheckType]map[common.StorageType]string{
+		requiredFiles: map[common.CheckType]map[common.StorageType][]string{
 			common.CheckTypeUser: {
-				common.StorageTypeSQLite: "users.db",
-				common.StorageTypeBinary: "users.bin",
-				common.StorageTypeCSV:    "users.csv",
+				common.StorageTypeSQLite: {"users.db"},
+				common.StorageTypeBinary: {"users.bin"},
+				common.StorageTypeCSV:    csv.Filenames(common.CheckTypeUser),
+				common.StorageTypeJSONL:  {jsonl.Filename(common.CheckTypeUser)},
+				common.StorageTypeCDB:    {cdb.Filename(common.CheckTypeUser)},
 			},
 			common.CheckTypeGroup: {
-				common.StorageTypeSQLite: "groups.db",
-				common.StorageTypeBinary: "groups.bin",
-				common.StorageTypeCSV:    "groups.csv",
+				common.StorageTypeSQLite: {"groups.db"},
+				common.StorageTypeBinary: {"groups.bin"},
+				common.StorageTypeCSV:    csv.Filenames(common.CheckTypeGroup),
+				common.StorageTypeJSONL:  {jsonl.Filename(common.CheckTypeGroup)},
+				common.StorageTypeCDB:    {cdb.Filename(common.CheckTypeGroup)},
 			},
 		},
 	}
@@ -47,8 +58,10 @@ func (v *Validator) GetExportDirs(baseDir string) ([]string, error) {
 
 	// Build valid files map
 	for _, files := range v.requiredFiles {
-		for _, filename := range files {
-			validFiles[filename] = struct{}{}
+		for _, filenames := range files {
+			for _, filename := range filenames {
+				validFiles[filename] = struct{}{}
+			}
 		}
 	}
 
@@ -100,10 +113,76 @@ func (v *Validator) GetExportDirs(baseDir string) ([]string, error) {
 
 // ValidateExportDir ensures required files exist in the directory for the given storage type.
 func (v *Validator) ValidateExportDir(dir string, checkType common.CheckType, storageType common.StorageType) error {
-	filename := v.requiredFiles[checkType][storageType]
-	path := filepath.Join(dir, filename)
-	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("%w: %s file for %s check: %s", ErrMissingFile, storageType, checkType, filename)
+	_, err := v.findFile(dir, checkType, storageType)
+	return err
+}
+
+// findFile returns the name of the first file present in the directory for the given storage type.
+func (v *Validator) findFile(dir string, checkType common.CheckType, storageType common.StorageType) (string, error) {
+	filenames := v.requiredFiles[fileCheckType(checkType)][storageType.FileType()]
+	for _, filename := range filenames {
+		if _, err := os.Stat(filepath.Join(dir, filename)); err == nil {
+			return filename, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s file for %s check: %s", ErrMissingFile, storageType, checkType, strings.Join(filenames, " or "))
+}
+
+// GetStorageTypes returns the storage types with files present in the directory for the given check type.
+func (v *Validator) GetStorageTypes(dir string, checkType common.CheckType) []common.StorageType {
+	files := v.requiredFiles[fileCheckType(checkType)]
+	storageTypes := make([]common.StorageType, 0, len(files))
+	for storageType := range files {
+		if v.ValidateExportDir(dir, checkType, storageType) == nil {
+			storageTypes = append(storageTypes, storageType)
+		}
+	}
+
+	slices.Sort(storageTypes)
+	return storageTypes
+}
+
+// HasBloomFilter reports whether the directory has a bloom filter sidecar for the given check type.
+func (v *Validator) HasBloomFilter(dir string, checkType common.CheckType) bool {
+	_, err := os.Stat(filepath.Join(dir, bloom.Filename(checkType)))
+	return err == nil
+}
+
+// ExportFiles returns the paths of the known export files present in the directory:
+// the storage files, the bloom filter sidecars and the export configuration.
+func (v *Validator) ExportFiles(dir string) []string {
+	filenames := []string{config.Filename}
+	for checkType := range v.requiredFiles {
+		filenames = append(filenames, bloom.Filename(checkType))
+		filenames = append(filenames, v.storageFilenames(checkType)...)
+	}
+	slices.Sort(filenames)
+
+	var paths []string
+	for _, filename := range slices.Compact(filenames) {
+		path := filepath.Join(dir, filename)
+		if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// storageFilenames returns the names every storage file of the check type may have.
+func (v *Validator) storageFilenames(checkType common.CheckType) []string {
+	var filenames []string
+	for _, names := range v.requiredFiles[fileCheckType(checkType)] {
+		filenames = append(filenames, names...)
+	}
+	slices.Sort(filenames)
+	return filenames
+}
+
+// fileCheckType returns the check type whose storage files hold the hashes of the given check type.
+// Friends checks look up user hashes.
+func fileCheckType(checkType common.CheckType) common.CheckType {
+	if checkType == common.CheckTypeFriends {
+		return common.CheckTypeUser
 	}
-	return nil
+	return checkType
 }
@@ -16,12 +16,16 @@ func TestNewValidator(t *testing.T) {
 	require.NotNil(t, v.requiredFiles)
 
 	// Verify required files are properly initialized
-	assert.Equal(t, "users.db", v.requiredFiles[common.CheckTypeUser][common.StorageTypeSQLite])
-	assert.Equal(t, "users.bin", v.requiredFiles[common.CheckTypeUser][common.StorageTypeBinary])
-	assert.Equal(t, "users.csv", v.requiredFiles[common.CheckTypeUser][common.StorageTypeCSV])
-	assert.Equal(t, "groups.db", v.requiredFiles[common.CheckTypeGroup][common.StorageTypeSQLite])
-	assert.Equal(t, "groups.bin", v.requiredFiles[common.CheckTypeGroup][common.StorageTypeBinary])
-	assert.Equal(t, "groups.csv", v.requiredFiles[common.CheckTypeGroup][common.StorageTypeCSV])
+	assert.Equal(t, []string{"users.db"}, v.requiredFiles[common.CheckTypeUser][common.StorageTypeSQLite])
+	assert.Equal(t, []string{"users.bin"}, v.requiredFiles[common.CheckTypeUser][common.StorageTypeBinary])
+	assert.Equal(t, []string{"users.csv", "users.csv.gz"}, v.requiredFiles[common.CheckTypeUser][common.StorageTypeCSV])
+	assert.Equal(t, []string{"groups.db"}, v.requiredFiles[common.CheckTypeGroup][common.StorageTypeSQLite])
+	assert.Equal(t, []string{"groups.bin"}, v.requiredFiles[common.CheckTypeGroup][common.StorageTypeBinary])
+	assert.Equal(t, []string{"groups.csv", "groups.csv.gz"}, v.requiredFiles[common.CheckTypeGroup][common.StorageTypeCSV])
+	assert.Equal(t, []string{"users.jsonl"}, v.requiredFiles[common.CheckTypeUser][common.StorageTypeJSONL])
+	assert.Equal(t, []string{"groups.jsonl"}, v.requiredFiles[common.CheckTypeGroup][common.StorageTypeJSONL])
+	assert.Equal(t, []string{"users.cdb"}, v.requiredFiles[common.CheckTypeUser][common.StorageTypeCDB])
+	assert.Equal(t, []string{"groups.cdb"}, v.requiredFiles[common.CheckTypeGroup][common.StorageTypeCDB])
 }
 
 func TestValidator_GetExportDirs(t *testing.T) {
@@ -141,6 +145,24 @@ func TestValidator_ValidateExportDir(t *testing.T) {
 			storageType: common.StorageTypeCSV,
 			wantError:   false,
 		},
+		{
+			name:        "Valid user indexed CSV",
+			checkType:   common.CheckTypeUser,
+			storageType: common.StorageTypeCSVIndexed,
+			wantError:   false,
+		},
+		{
+			name:        "Valid group indexed binary",
+			checkType:   common.CheckTypeGroup,
+			storageType: common.StorageTypeBinaryIndexed,
+			wantError:   false,
+		},
+		{
+			name:        "Friends use the user files",
+			checkType:   common.CheckTypeFriends,
+			storageType: common.StorageTypeCSV,
+			wantError:   false,
+		},
 		{
 			name:        "Missing file",
 			checkType:   common.CheckTypeUser,
@@ -167,3 +189,30 @@ func TestValidator_ValidateExportDir(t *testing.T) {
 		})
 	}
 }
+
+func TestValidator_GetStorageTypes(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, file := range []string{"users.db", "users.csv", "groups.bin"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), nil, 0o600))
+	}
+
+	v := NewValidator()
+	assert.Equal(t,
+		[]common.StorageType{common.StorageTypeCSV, common.StorageTypeSQLite},
+		v.GetStorageTypes(tempDir, common.CheckTypeUser))
+	assert.Equal(t,
+		[]common.StorageType{common.StorageTypeBinary},
+		v.GetStorageTypes(tempDir, common.CheckTypeGroup))
+	assert.Equal(t, v.GetStorageTypes(tempDir, common.CheckTypeUser), v.GetStorageTypes(tempDir, common.CheckTypeFriends))
+	assert.Empty(t, v.GetStorageTypes(filepath.Join(tempDir, "missing"), common.CheckTypeUser))
+
+	// Compressed CSV files are discovered too
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "groups.csv.gz"), nil, 0o600))
+	assert.Equal(t,
+		[]common.StorageType{common.StorageTypeBinary, common.StorageTypeCSV},
+		v.GetStorageTypes(tempDir, common.CheckTypeGroup))
+
+	dirs, err := v.GetExportDirs(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tempDir}, dirs)
+}
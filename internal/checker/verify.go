@@ -0,0 +1,251 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/checker/binary"
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/checker/cdb"
+	"github.com/robalyx/rotten/internal/checker/csv"
+	"github.com/robalyx/rotten/internal/checker/jsonl"
+	"github.com/robalyx/rotten/internal/checker/sqlite"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/robalyx/rotten/internal/hasher"
+)
+
+// MaxReportedProblems is the maximum number of problems kept per file and differences kept per comparison.
+const MaxReportedProblems = 20
+
+var (
+	ErrNoStorageFiles   = errors.New("no storage files found")
+	ErrDuplicateHash    = errors.New("duplicate hash")
+	ErrRecordMismatch   = errors.New("record counts differ")
+	ErrMissingFromFiles = errors.New("hash missing")
+)
+
+// FileReport contains the verification result of a single storage file.
+type FileReport struct {
+	CheckType     common.CheckType
+	StorageType   common.StorageType
+	Filename      string
+	Records       int     // Number of valid records
+	Problems      []error // Corrupt records, up to MaxReportedProblems
+	TotalProblems int     // Number of corrupt records including those not kept
+	Err           error   // Set if the file could not be read
+
+	hashes map[string]struct{}
+}
+
+// OK reports whether the file was read without problems.
+func (f *FileReport) OK() bool {
+	return f.Err == nil && f.TotalProblems == 0
+}
+
+// addProblem records a corrupt record.
+func (f *FileReport) addProblem(err error) {
+	f.TotalProblems++
+	if len(f.Problems) < MaxReportedProblems {
+		f.Problems = append(f.Problems, err)
+	}
+}
+
+// BloomReport contains the verification result of a bloom filter sidecar.
+type BloomReport struct {
+	CheckType         common.CheckType
+	Filename          string
+	Hashes            uint64  // Number of hashes the filter was built from
+	FalsePositiveRate float64 // Expected false-positive rate
+	Missing           []error // Hashes in the storage files that the filter rules out
+	Err               error   // Set if the filter could not be read
+}
+
+// OK reports whether the filter was read and holds every hash of the storage files.
+func (b *BloomReport) OK() bool {
+	return b.Err == nil && len(b.Missing) == 0
+}
+
+// VerifyReport contains the result of verifying an export directory.
+type VerifyReport struct {
+	Config       *config.Config
+	ConfigErr    error
+	Files        []*FileReport
+	Mismatches   []error // Differences between storage formats of the same check type
+	BloomFilters []*BloomReport
+}
+
+// OK reports whether the export passed every check.
+func (r *VerifyReport) OK() bool {
+	if r.ConfigErr != nil || len(r.Files) == 0 || len(r.Mismatches) > 0 {
+		return false
+	}
+	for _, file := range r.Files {
+		if !file.OK() {
+			return false
+		}
+	}
+	for _, filter := range r.BloomFilters {
+		if !filter.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify validates the export configuration, fully parses every storage file in the directory,
+// and checks that all storage formats of the same check type contain the same hashes.
+func (v *Validator) Verify(dir string) *VerifyReport {
+	report := &VerifyReport{}
+	report.Config, report.ConfigErr = config.LoadOrCreate(dir)
+
+	for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+		var files []*FileReport
+		for _, storageType := range v.GetStorageTypes(dir, checkType) {
+			filename, _ := v.findFile(dir, checkType, storageType)
+			file := verifyFile(dir, checkType, storageType, filename)
+			report.Files = append(report.Files, file)
+			if file.Err == nil {
+				files = append(files, file)
+			}
+		}
+
+		report.Mismatches = append(report.Mismatches, compareFiles(files)...)
+
+		if v.HasBloomFilter(dir, checkType) {
+			report.BloomFilters = append(report.BloomFilters, verifyBloomFilter(dir, checkType, files))
+		}
+	}
+
+	if len(report.Files) == 0 && report.ConfigErr == nil {
+		report.ConfigErr = fmt.Errorf("%w in %s", ErrNoStorageFiles, dir)
+	}
+
+	return report
+}
+
+// verifyFile parses a single storage file and collects its hashes.
+func verifyFile(dir string, checkType common.CheckType, storageType common.StorageType, filename string) *FileReport {
+	file := &FileReport{
+		CheckType:   checkType,
+		StorageType: storageType,
+		Filename:    filename,
+		hashes:      make(map[string]struct{}),
+	}
+
+	collect := func(hash string, _ *common.CheckResult) error {
+		if _, ok := file.hashes[hash]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateHash, hash)
+		}
+		file.hashes[hash] = struct{}{}
+		file.Records++
+		return nil
+	}
+
+	problems, err := walkFile(dir, checkType, storageType, collect)
+	file.Err = err
+
+	for _, problem := range problems {
+		file.addProblem(problem)
+	}
+
+	return file
+}
+
+// verifyBloomFilter checks that the bloom filter accepts every hash of the storage files.
+// A filter that rules out a hash in the export would hide it from lookups.
+func verifyBloomFilter(dir string, checkType common.CheckType, files []*FileReport) *BloomReport {
+	report := &BloomReport{CheckType: checkType, Filename: bloom.Filename(checkType)}
+
+	filter, err := bloom.Load(dir, checkType)
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	report.Hashes = filter.Count()
+	report.FalsePositiveRate = filter.FalsePositiveRate()
+
+	missing := make(map[string]struct{})
+	for _, file := range files {
+		for hash := range file.hashes {
+			if _, ok := missing[hash]; ok || filter.Test(hash) {
+				continue
+			}
+
+			missing[hash] = struct{}{}
+			if len(report.Missing) < MaxReportedProblems {
+				report.Missing = append(report.Missing, fmt.Errorf("%w: %s is in %s",
+					ErrBloomMissingHash, hash, file.Filename))
+			}
+		}
+	}
+	if len(missing) > len(report.Missing) {
+		report.Missing = append(report.Missing, fmt.Errorf("%w: %d more hashes",
+			ErrBloomMissingHash, len(missing)-len(report.Missing)))
+	}
+
+	return report
+}
+
+// walkFile calls fn with every valid record in the storage file, returning the corrupt records as problems.
+func walkFile(dir string, checkType common.CheckType, storageType common.StorageType, fn common.RecordFunc) ([]error, error) {
+	switch storageType.FileType() {
+	case common.StorageTypeSQLite:
+		return sqlite.Walk(dir, checkType, fn)
+	case common.StorageTypeBinary:
+		return binary.Walk(dir, checkType, hasher.Size, fn)
+	case common.StorageTypeCSV:
+		return csv.Walk(dir, checkType, fn)
+	case common.StorageTypeJSONL:
+		return jsonl.Walk(dir, checkType, fn)
+	case common.StorageTypeCDB:
+		return cdb.Walk(dir, checkType, fn)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedStorageType, storageType)
+	}
+}
+
+// compareFiles checks that every file holds the same hashes as the first one.
+func compareFiles(files []*FileReport) []error {
+	if len(files) < 2 {
+		return nil
+	}
+
+	var mismatches []error
+	base := files[0]
+	for _, other := range files[1:] {
+		if base.Records != other.Records {
+			mismatches = append(mismatches, fmt.Errorf("%w: %s has %d records but %s has %d",
+				ErrRecordMismatch, base.Filename, base.Records, other.Filename, other.Records))
+		}
+
+		mismatches = append(mismatches, missingHashes(base, other)...)
+		mismatches = append(mismatches, missingHashes(other, base)...)
+	}
+
+	return mismatches
+}
+
+// missingHashes returns the hashes in from that are not in to, up to MaxReportedProblems.
+func missingHashes(from, to *FileReport) []error {
+	var missing []error
+	total := 0
+	for hash := range from.hashes {
+		if _, ok := to.hashes[hash]; ok {
+			continue
+		}
+
+		total++
+		if len(missing) < MaxReportedProblems {
+			missing = append(missing, fmt.Errorf("%w: %s is in %s but not in %s",
+				ErrMissingFromFiles, hash, from.Filename, to.Filename))
+		}
+	}
+
+	if total > len(missing) {
+		missing = append(missing, fmt.Errorf("%w: %d more hashes in %s are not in %s",
+			ErrMissingFromFiles, total-len(missing), from.Filename, to.Filename))
+	}
+
+	return missing
+}
@@ -0,0 +1,188 @@
+package checker
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// testRecord is a record written to every storage format of a test export.
+type testRecord struct {
+	hash       string
+	status     string
+	reason     string
+	confidence float64
+}
+
+// testHash returns a 32-byte hex hash derived from n.
+func testHash(n int) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+// writeExport writes the records to SQLite, binary and CSV files for the check type.
+func writeExport(t *testing.T, dir string, name string, records []testRecord) {
+	t.Helper()
+
+	// SQLite
+	conn, err := sqlite.OpenConn(filepath.Join(dir, name+".db"), sqlite.OpenCreate|sqlite.OpenReadWrite)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, sqlitex.ExecScript(conn, `CREATE TABLE `+name+` (
+		hash TEXT PRIMARY KEY, status TEXT NOT NULL, reason TEXT NOT NULL, confidence REAL NOT NULL
+	);`))
+	for _, r := range records {
+		require.NoError(t, sqlitex.Execute(conn, "INSERT INTO "+name+" VALUES (?, ?, ?, ?)",
+			&sqlitex.ExecOptions{Args: []any{r.hash, r.status, r.reason, r.confidence}}))
+	}
+
+	// Binary
+	f, err := os.Create(filepath.Join(dir, name+".bin"))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, binary.Write(f, binary.LittleEndian, uint32(len(records))))
+	for _, r := range records {
+		hash, err := hex.DecodeString(r.hash)
+		require.NoError(t, err)
+		_, err = f.Write(hash)
+		require.NoError(t, err)
+		for _, s := range []string{r.status, r.reason} {
+			require.NoError(t, binary.Write(f, binary.LittleEndian, uint16(len(s))))
+			_, err = f.WriteString(s)
+			require.NoError(t, err)
+		}
+		require.NoError(t, binary.Write(f, binary.LittleEndian, r.confidence))
+	}
+
+	// CSV
+	var sb strings.Builder
+	sb.WriteString("hash,status,reason,confidence\n")
+	for _, r := range records {
+		fmt.Fprintf(&sb, "%s,%s,%s,%g\n", r.hash, r.status, r.reason, r.confidence)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".csv"), []byte(sb.String()), 0o600))
+}
+
+// setupVerifyExport creates an export with matching records in every storage format.
+func setupVerifyExport(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := &config.Config{EngineVersion: "1.0.0", ExportVersion: "1.0.0", Salt: "salt", HashType: "sha256"}
+	require.NoError(t, cfg.Save(dir))
+
+	writeExport(t, dir, "users", []testRecord{
+		{hash: testHash(1), status: "confirmed", reason: "a; b", confidence: 0.9},
+		{hash: testHash(2), status: "flagged", reason: "c", confidence: 0.5},
+	})
+	writeExport(t, dir, "groups", []testRecord{
+		{hash: testHash(3), status: "flagged", reason: "d", confidence: 1},
+	})
+
+	return dir
+}
+
+func TestValidator_Verify(t *testing.T) {
+	dir := setupVerifyExport(t)
+
+	report := NewValidator().Verify(dir)
+	assert.True(t, report.OK())
+	assert.NoError(t, report.ConfigErr)
+	assert.Empty(t, report.Mismatches)
+	require.Len(t, report.Files, 6)
+	for _, file := range report.Files {
+		assert.True(t, file.OK(), file.Filename)
+		if file.CheckType == common.CheckTypeUser {
+			assert.Equal(t, 2, file.Records, file.Filename)
+		} else {
+			assert.Equal(t, 1, file.Records, file.Filename)
+		}
+	}
+}
+
+func TestValidator_Verify_CorruptCSV(t *testing.T) {
+	dir := setupVerifyExport(t)
+	content := "hash,status,reason,confidence\n" +
+		testHash(1) + ",confirmed,a; b,0.9\n" +
+		testHash(2) + ",flagged,c,high\n" +
+		"not-hex,flagged,c,0.5\n" +
+		testHash(1) + ",confirmed,a; b,0.9\n" +
+		"too,few\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	report := NewValidator().Verify(dir)
+	assert.False(t, report.OK())
+
+	var csvFile *FileReport
+	for _, file := range report.Files {
+		if file.Filename == "users.csv" {
+			csvFile = file
+		}
+	}
+	require.NotNil(t, csvFile)
+	assert.Equal(t, 1, csvFile.Records)
+	require.Equal(t, 4, csvFile.TotalProblems)
+	assert.Contains(t, csvFile.Problems[0].Error(), "line 3: invalid confidence value")
+	assert.ErrorIs(t, csvFile.Problems[1], common.ErrInvalidRecordHash)
+	assert.Contains(t, csvFile.Problems[1].Error(), "line 4")
+	assert.ErrorIs(t, csvFile.Problems[2], ErrDuplicateHash)
+	assert.Contains(t, csvFile.Problems[2].Error(), "line 5")
+	assert.Contains(t, csvFile.Problems[3].Error(), "line 6")
+
+	// Hash missing from the CSV file is reported against the other formats
+	require.NotEmpty(t, report.Mismatches)
+	assert.ErrorIs(t, report.Mismatches[0], ErrRecordMismatch)
+	assert.Contains(t, report.Mismatches[0].Error(), "users.csv has 1")
+}
+
+func TestValidator_Verify_TruncatedBinary(t *testing.T) {
+	dir := setupVerifyExport(t)
+	path := filepath.Join(dir, "users.bin")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-4], 0o600))
+
+	report := NewValidator().Verify(dir)
+	assert.False(t, report.OK())
+
+	for _, file := range report.Files {
+		if file.Filename != "users.bin" {
+			continue
+		}
+		assert.Equal(t, 1, file.Records)
+		require.Len(t, file.Problems, 1)
+		assert.Contains(t, file.Problems[0].Error(), "confidence runs past end of file")
+		assert.Contains(t, file.Problems[0].Error(), fmt.Sprintf("offset %d", 4+32+2+len("confirmed")+2+len("a; b")+8))
+	}
+}
+
+func TestValidator_Verify_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	report := NewValidator().Verify(dir)
+	assert.False(t, report.OK())
+	assert.Error(t, report.ConfigErr)
+	assert.Empty(t, report.Files)
+}
+
+func TestValidator_Verify_JSONL(t *testing.T) {
+	dir := setupVerifyExport(t)
+	users := `{"hash":"` + testHash(1) + `","status":"confirmed","reason":"a; b","confidence":0.9}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.jsonl"), []byte(users), 0o600))
+
+	// The JSON Lines file is compared with the other formats
+	report := NewValidator().Verify(dir)
+	assert.False(t, report.OK())
+	require.NotEmpty(t, report.Mismatches)
+	assert.Contains(t, report.Mismatches[0].Error(), "users.jsonl has 1")
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/output"
+)
+
+// batchChunkSize is the number of IDs looked up in the export at once.
+const batchChunkSize = 1000
+
+var ErrColumnNotFound = errors.New("column not found in input header")
+
+// runBatch handles the batch command.
+func (a *App) runBatch(ctx context.Context, args []string) int {
+	fs := a.newFlagSet("batch", "Usage: rotten batch --type <user|group> --input <file> --export-dir <dir> [flags]")
+	checkTypeFlag := fs.String("type", string(common.CheckTypeUser), "type of IDs in the input (user, group)")
+	input := fs.String("input", "", "file containing the IDs to check")
+	column := fs.String("column", "", "read IDs from this column of a CSV input with a header row")
+	report := fs.String("report", "-", "file to write the report to (- for stdout)")
+	outputFlag := fs.String("output", string(output.FormatCSV), "report format (csv, table, json, ndjson)")
+	exportDir := fs.String("export-dir", "", "export directory to check against")
+	storage := fs.String("storage", string(common.StorageTypeSQLite),
+		"storage type (sqlite, binary, csv, jsonl, cdb, csv-indexed, binary-indexed, remote)")
+	checkerOptions := checkerFlags(fs)
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 0 || *input == "" {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: an input file is required", ErrInvalidArguments))
+	}
+
+	// Friends checks require an API call per ID and are not supported in batches
+	checkType, err := parseCheckType(*checkTypeFlag)
+	if err != nil {
+		return a.fail(err)
+	}
+	if checkType == common.CheckTypeFriends {
+		return a.fail(fmt.Errorf("%w: batch checks support user and group IDs only", ErrInvalidCheckType))
+	}
+
+	format, err := output.ParseFormat(*outputFlag)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	// Read IDs from input file
+	ids, err := readIDFile(*input, *column)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	// Open export
+	sess, err := a.openSession(*exportDir, checkType, common.StorageType(strings.ToLower(*storage)), checkerOptions())
+	if err != nil {
+		return a.fail(err)
+	}
+	defer sess.close()
+
+	// Open report destination
+	reportOut := a.stdout
+	summary := a.stdout
+	if *report == "-" {
+		summary = a.stderr // Keep stdout clean for the report
+	} else {
+		file, err := os.Create(*report)
+		if err != nil {
+			return a.fail(fmt.Errorf("failed to create report: %w", err))
+		}
+		defer file.Close()
+		reportOut = file
+	}
+
+	// Check IDs in chunks
+	writer := output.NewListWriter(reportOut, format)
+	flaggedCount := 0
+	checkedCount := 0
+	var checkErr error
+	for chunk := range slices.Chunk(ids, batchChunkSize) {
+		results, err := sess.checkMany(ctx, checkType, chunk)
+		if results == nil {
+			return a.fail(fmt.Errorf("failed to check IDs: %w", err))
+		}
+
+		for i, result := range results {
+			if result.Found {
+				flaggedCount++
+			}
+			if err := writer.Write(sess.result(checkType, chunk[i], result)); err != nil {
+				return a.fail(err)
+			}
+		}
+		checkedCount += len(results)
+
+		// Keep the results checked so far if the batch was stopped
+		if err != nil {
+			checkErr = fmt.Errorf("batch stopped after %d of %d IDs: %w", checkedCount, len(ids), err)
+			break
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return a.fail(err)
+	}
+
+	fmt.Fprintf(summary, "%d flagged IDs found out of %d total IDs (%d clean)\n",
+		flaggedCount, checkedCount, checkedCount-flaggedCount)
+	if c, ok := sess.checker.(checker.IndexedChecker); ok && checkedCount > 0 {
+		if stats, err := c.LoadStats(context.WithoutCancel(ctx), lookupType(checkType)); err == nil {
+			fmt.Fprintf(summary, "Index: %s\n", stats)
+		}
+	}
+	if checkErr != nil {
+		return a.fail(checkErr)
+	}
+
+	if flaggedCount > 0 {
+		return ExitFlagged
+	}
+	return ExitClean
+}
+
+// readIDFile reads IDs from a file with one ID per line, or from a column of a CSV file.
+func readIDFile(path, column string) ([]uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input: %w", err)
+	}
+	defer file.Close()
+
+	if column != "" {
+		return readIDColumn(file, column)
+	}
+	return readIDLines(file)
+}
+
+// readIDLines reads one ID per line, skipping blank lines and lines starting with '#'.
+func readIDLines(r io.Reader) ([]uint64, error) {
+	ids := make([]uint64, 0)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID on line %d: %w", lineNum, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return ids, nil
+}
+
+// readIDColumn reads IDs from the named column of a CSV file with a header row.
+func readIDColumn(r io.Reader, column string) ([]uint64, error) {
+	reader := csv.NewReader(r)
+
+	// Find column index from header
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input header: %w", err)
+	}
+	index := slices.Index(header, column)
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+	}
+
+	ids := make([]uint64, 0)
+	for lineNum := 2; ; lineNum++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		value := strings.TrimSpace(record[index])
+		if value == "" {
+			continue
+		}
+
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID on line %d: %w", lineNum, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
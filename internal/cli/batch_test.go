@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Batch(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "first; second", confidence: 0.95}},
+		[]testRecord{{id: 2, status: "flagged", reason: "group reason", confidence: 0.5}},
+	)
+
+	inputDir := t.TempDir()
+	lineInput := filepath.Join(inputDir, "ids.txt")
+	require.NoError(t, os.WriteFile(lineInput, []byte("# staff\n1\n\n3\n4\n"), 0o600))
+	csvInput := filepath.Join(inputDir, "ids.csv")
+	require.NoError(t, os.WriteFile(csvInput, []byte("name,group_id\nfoo,2\nbar,5\n"), 0o600))
+
+	t.Run("Line input to stdout", func(t *testing.T) {
+		code, stdout, stderr := run(nil, "batch", "--type", "user", "--input", lineInput,
+			"--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitFlagged, code)
+		assert.Equal(t, "id,found,status,reason,confidence\n"+
+			"1,true,confirmed,first; second,0.95\n"+
+			"3,false,,,\n"+
+			"4,false,,,\n", stdout)
+		assert.Contains(t, stderr, "1 flagged IDs found out of 3 total IDs (2 clean)")
+	})
+
+	t.Run("CSV column input to report file", func(t *testing.T) {
+		report := filepath.Join(inputDir, "report.csv")
+		code, stdout, _ := run(nil, "batch", "--type", "group", "--input", csvInput, "--column", "group_id",
+			"--report", report, "--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitFlagged, code)
+		assert.Contains(t, stdout, "1 flagged IDs found out of 2 total IDs (1 clean)")
+
+		data, err := os.ReadFile(report)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "2,true,flagged,group reason,0.50\n")
+	})
+
+	t.Run("NDJSON report", func(t *testing.T) {
+		code, stdout, _ := run(nil, "batch", "--input", lineInput, "--output", "ndjson",
+			"--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitFlagged, code)
+		assert.Equal(t, 3, strings.Count(stdout, "\n"))
+		assert.Contains(t, stdout, `{"id":3,"checkType":"user"`)
+	})
+
+	t.Run("Indexed storage", func(t *testing.T) {
+		code, stdout, stderr := run(nil, "batch", "--type", "user", "--input", lineInput,
+			"--export-dir", dir, "--storage", "csv-indexed")
+		assert.Equal(t, ExitFlagged, code)
+		assert.Contains(t, stdout, "1,true,confirmed,first; second,0.95\n")
+		assert.Contains(t, stderr, "Index: 1 hashes loaded in")
+	})
+
+	t.Run("All clean", func(t *testing.T) {
+		code, _, _ := run(nil, "batch", "--type", "group", "--input", lineInput,
+			"--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitClean, code)
+	})
+
+	t.Run("Missing column", func(t *testing.T) {
+		code, _, stderr := run(nil, "batch", "--input", csvInput, "--column", "user_id",
+			"--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, ErrColumnNotFound.Error())
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		invalid := filepath.Join(inputDir, "invalid.txt")
+		require.NoError(t, os.WriteFile(invalid, []byte("1\nabc\n"), 0o600))
+
+		code, _, stderr := run(nil, "batch", "--input", invalid, "--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "invalid ID on line 2")
+	})
+
+	t.Run("Friends not supported", func(t *testing.T) {
+		code, _, stderr := run(nil, "batch", "--type", "friends", "--input", lineInput,
+			"--export-dir", dir, "--storage", "csv")
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "user and group IDs only")
+	})
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// runBloom handles the bloom command.
+func (a *App) runBloom(args []string) int {
+	fs := a.newFlagSet("bloom", "Usage: rotten bloom <export-dir> [flags]")
+	checkTypeFlag := fs.String("type", "", "only generate the filter for this check type (user, group)")
+	storage := fs.String("storage", "", "storage type to read the hashes from, the only one the filter is used with (default: the first one found)")
+	falsePositiveRate := fs.Float64("fp-rate", bloom.DefaultFalsePositiveRate, "target false-positive rate")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export directory", ErrInvalidArguments))
+	}
+	dir := positional[0]
+
+	// Determine which check types to generate filters for
+	checkTypes := []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup}
+	if *checkTypeFlag != "" {
+		checkType, err := parseCheckType(*checkTypeFlag)
+		if err != nil {
+			return a.fail(err)
+		}
+		checkTypes = []common.CheckType{lookupType(checkType)}
+	}
+
+	validator := checker.NewValidator()
+	generated := 0
+	for _, checkType := range checkTypes {
+		storageType := common.StorageType(strings.ToLower(*storage))
+		if storageType == "" {
+			storageTypes := validator.GetStorageTypes(dir, checkType)
+			if len(storageTypes) == 0 {
+				continue // Exports may contain only one check type
+			}
+			storageType = storageTypes[0]
+		} else if err := validator.ValidateExportDir(dir, checkType, storageType); err != nil {
+			if *checkTypeFlag != "" {
+				return a.fail(fmt.Errorf("invalid export directory: %w", err))
+			}
+			continue
+		}
+
+		filter, err := checker.GenerateBloomFilter(dir, checkType, storageType, *falsePositiveRate)
+		if err != nil {
+			return a.fail(err)
+		}
+		if err := filter.Save(dir, checkType); err != nil {
+			return a.fail(err)
+		}
+
+		fmt.Fprintf(a.stdout, "Wrote %s from %s: %d hashes, %.2f%% false positives, %d bytes\n",
+			bloom.Filename(checkType), storageType, filter.Count(), filter.FalsePositiveRate()*100, filter.Size())
+		generated++
+	}
+
+	if generated == 0 {
+		return a.fail(fmt.Errorf("%w: no storage files found in %s", ErrInvalidArguments, dir))
+	}
+	return ExitClean
+}
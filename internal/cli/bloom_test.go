@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Bloom(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}},
+		[]testRecord{{id: 2, status: "flagged", reason: "reason", confidence: 0.5}},
+	)
+
+	code, stdout, stderr := run(nil, "bloom", dir)
+	require.Equal(t, ExitClean, code, stderr)
+	assert.Contains(t, stdout, "Wrote users.bloom from csv: 1 hashes")
+	assert.Contains(t, stdout, "Wrote groups.bloom from csv: 1 hashes")
+	assert.FileExists(t, filepath.Join(dir, "users.bloom"))
+	assert.FileExists(t, filepath.Join(dir, "groups.bloom"))
+
+	// Lookups consult the filters
+	code, stdout, _ = run(nil, "check", "user", "1", "--export-dir", dir, "--storage", "csv")
+	assert.Equal(t, ExitFlagged, code)
+	assert.Contains(t, stdout, "User ID 1 was FOUND")
+
+	code, stdout, _ = run(nil, "check", "user", "3", "--export-dir", dir, "--storage", "csv")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "User ID 3 was NOT FOUND")
+
+	code, stdout, _ = run(nil, "verify", dir)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "users.bloom: OK (1 hashes")
+	assert.Contains(t, stdout, "groups.bloom: OK (1 hashes")
+
+	// A corrupt filter fails checker creation
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "groups.bloom"), []byte("corrupt"), 0o600))
+	code, _, stderr = run(nil, "check", "group", "2", "--export-dir", dir, "--storage", "csv")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "invalid bloom filter format")
+
+	code, _, _ = run(nil, "bloom", dir, "--type", "group")
+	assert.Equal(t, ExitClean, code)
+
+	code, _, stderr = run(nil, "bloom", dir, "--fp-rate", "1.5")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "false-positive rate must be between 0 and 1")
+
+	code, _, stderr = run(nil, "bloom", dir, "--type", "user", "--storage", "sqlite")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "invalid export directory")
+
+	code, _, _ = run(nil, "bloom")
+	assert.Equal(t, ExitError, code)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/cdb"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// runCDB handles the cdb command.
+func (a *App) runCDB(args []string) int {
+	fs := a.newFlagSet("cdb", "Usage: rotten cdb <export-dir> [flags]")
+	checkTypeFlag := fs.String("type", "", "only build the file for this check type (user, group)")
+	storage := fs.String("storage", "", "storage type to read the records from (default: the first one found)")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export directory", ErrInvalidArguments))
+	}
+	dir := positional[0]
+
+	// Determine which check types to build files for
+	checkTypes := []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup}
+	if *checkTypeFlag != "" {
+		checkType, err := parseCheckType(*checkTypeFlag)
+		if err != nil {
+			return a.fail(err)
+		}
+		checkTypes = []common.CheckType{lookupType(checkType)}
+	}
+
+	validator := checker.NewValidator()
+	generated := 0
+	for _, checkType := range checkTypes {
+		storageType := common.StorageType(strings.ToLower(*storage))
+		if storageType == "" {
+			storageTypes := validator.GetStorageTypes(dir, checkType)
+			if len(storageTypes) == 0 {
+				continue // Exports may contain only one check type
+			}
+
+			// Prefer any other format to rebuilding the file from itself
+			storageType = storageTypes[0]
+			for _, candidate := range storageTypes {
+				if candidate != common.StorageTypeCDB {
+					storageType = candidate
+					break
+				}
+			}
+		} else if err := validator.ValidateExportDir(dir, checkType, storageType); err != nil {
+			if *checkTypeFlag != "" {
+				return a.fail(fmt.Errorf("invalid export directory: %w", err))
+			}
+			continue
+		}
+
+		count, err := checker.GenerateCDB(dir, checkType, storageType)
+		if err != nil {
+			return a.fail(err)
+		}
+		stat, err := os.Stat(filepath.Join(dir, cdb.Filename(checkType)))
+		if err != nil {
+			return a.fail(err)
+		}
+
+		fmt.Fprintf(a.stdout, "Wrote %s from %s: %d records, %d bytes\n", cdb.Filename(checkType), storageType, count, stat.Size())
+		generated++
+	}
+
+	if generated == 0 {
+		return a.fail(fmt.Errorf("%w: no storage files found in %s", ErrInvalidArguments, dir))
+	}
+	return ExitClean
+}
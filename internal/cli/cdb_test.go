@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_CDB(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{
+			{id: 1, status: "confirmed", reason: "reason", confidence: 0.95},
+			{id: 3, status: "flagged", reason: "other", confidence: 0.5},
+		},
+		[]testRecord{{id: 2, status: "flagged", reason: "reason", confidence: 0.5}},
+	)
+
+	code, stdout, stderr := run(nil, "cdb", dir)
+	require.Equal(t, ExitClean, code, stderr)
+	assert.Contains(t, stdout, "Wrote users.cdb from csv: 2 records")
+	assert.Contains(t, stdout, "Wrote groups.cdb from csv: 1 records")
+
+	// Lookups read the new files
+	code, stdout, _ = run(nil, "check", "user", "3", "--export-dir", dir, "--storage", "cdb")
+	assert.Equal(t, ExitFlagged, code)
+	assert.Contains(t, stdout, "User ID 3 was FOUND")
+
+	code, stdout, _ = run(nil, "check", "group", "1", "--export-dir", dir, "--storage", "cdb")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "Group ID 1 was NOT FOUND")
+
+	code, stdout, _ = run(nil, "verify", dir)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "users.cdb: OK")
+
+	// Without other formats, the files are rebuilt from themselves
+	require.NoError(t, os.Remove(filepath.Join(dir, "groups.csv")))
+	code, stdout, stderr = run(nil, "cdb", dir, "--type", "group")
+	assert.Equal(t, ExitClean, code, stderr)
+	assert.Contains(t, stdout, "Wrote groups.cdb from cdb: 1 records")
+
+	code, _, stderr = run(nil, "cdb", dir, "--type", "user", "--storage", "sqlite")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "invalid export directory")
+
+	code, _, _ = run(nil, "cdb")
+	assert.Equal(t, ExitError, code)
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/output"
+)
+
+// runCheck handles the check command.
+func (a *App) runCheck(ctx context.Context, args []string) int {
+	fs := a.newFlagSet("check", "Usage: rotten check <user|group|friends> <id> --export-dir <dir> [flags]\n"+
+		"       rotten check <user|group> --stdin --export-dir <dir> [flags]")
+	exportDir := fs.String("export-dir", "", "export directory to check against")
+	storage := fs.String("storage", string(common.StorageTypeSQLite),
+		"storage type (sqlite, binary, csv, jsonl, cdb, csv-indexed, binary-indexed, remote)")
+	checkerOptions := checkerFlags(fs)
+	outputFlag := fs.String("output", string(output.FormatTable), "output format (table, json, ndjson, csv)")
+	stdin := fs.Bool("stdin", false, "read IDs line by line from stdin and write one result per line")
+	unordered := fs.Bool("unordered", false, "with --stdin, write results as soon as they are ready instead of in input order")
+	workers := fs.Int("workers", runtime.NumCPU(), "with --stdin, number of IDs to hash and check concurrently")
+	timeout := fs.Duration("timeout", 0, "stop checking after this long, e.g. 30s (0 for no limit)")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	if *stdin {
+		return a.runCheckStream(ctx, fs, positional, *exportDir, *storage, checkerOptions(), *outputFlag, *workers, *unordered)
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected a check type and an ID", ErrInvalidArguments))
+	}
+
+	// Parse check type and ID
+	checkType, err := parseCheckType(positional[0])
+	if err != nil {
+		return a.fail(err)
+	}
+	id, err := strconv.ParseUint(positional[1], 10, 64)
+	if err != nil {
+		return a.fail(fmt.Errorf("invalid ID format: %w", err))
+	}
+	format, err := output.ParseFormat(*outputFlag)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	// Open export
+	sess, err := a.openSession(*exportDir, checkType, common.StorageType(strings.ToLower(*storage)), checkerOptions())
+	if err != nil {
+		return a.fail(err)
+	}
+	defer sess.close()
+
+	var res *output.Result
+	var checkErr error
+	if checkType == common.CheckTypeFriends {
+		res, checkErr = a.checkFriends(ctx, sess, id)
+	} else {
+		res, checkErr = a.checkSingle(ctx, sess, checkType, id)
+	}
+	if res == nil {
+		return a.fail(checkErr)
+	}
+
+	// Write result, which may be partial if the check was stopped
+	writer := output.NewWriter(a.stdout, format)
+	if err := writer.Write(res); err != nil {
+		return a.fail(err)
+	}
+	if err := writer.Flush(); err != nil {
+		return a.fail(err)
+	}
+
+	// A stopped check is an error unless flagged friends were already found
+	switch {
+	case res.Flagged():
+		if checkErr != nil {
+			fmt.Fprintf(a.stderr, "Error: %v\n", checkErr)
+		}
+		return ExitFlagged
+	case checkErr != nil:
+		return a.fail(checkErr)
+	default:
+		return ExitClean
+	}
+}
+
+// runCheckStream validates the arguments of a stdin check and starts the stream.
+func (a *App) runCheckStream(
+	ctx context.Context, fs *flag.FlagSet, positional []string, exportDir, storage string, opts checker.Options,
+	outputFlag string, workers int, unordered bool,
+) int {
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected only a check type with --stdin", ErrInvalidArguments))
+	}
+	if workers < 1 {
+		return a.fail(fmt.Errorf("%w: workers must be at least 1", ErrInvalidArguments))
+	}
+
+	checkType, err := parseCheckType(positional[0])
+	if err != nil {
+		return a.fail(err)
+	}
+	if checkType == common.CheckTypeFriends {
+		return a.fail(fmt.Errorf("%w: stdin checks support user and group IDs only", ErrInvalidCheckType))
+	}
+
+	// Only line-based formats can be streamed, so default to NDJSON
+	format := output.FormatNDJSON
+	if isFlagSet(fs, "output") {
+		if format, err = output.ParseFormat(outputFlag); err != nil {
+			return a.fail(err)
+		}
+		if format != output.FormatNDJSON && format != output.FormatCSV {
+			return a.fail(fmt.Errorf("%w: --stdin supports ndjson and csv output only", output.ErrUnsupportedFormat))
+		}
+	}
+
+	sess, err := a.openSession(exportDir, checkType, common.StorageType(strings.ToLower(storage)), opts)
+	if err != nil {
+		return a.fail(err)
+	}
+	defer sess.close()
+
+	return a.checkStream(ctx, a.stdin, sess, checkType, format, workers, unordered)
+}
+
+// checkSingle checks a user or group ID.
+func (a *App) checkSingle(ctx context.Context, sess *session, checkType common.CheckType, id uint64) (*output.Result, error) {
+	result, err := sess.check(ctx, checkType, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ID: %w", err)
+	}
+	return sess.result(checkType, id, result), nil
+}
+
+// checkFriends checks every friend of the user and collects the flagged ones.
+// If the context is done during the check, the result of the friends checked so far is returned with the error.
+func (a *App) checkFriends(ctx context.Context, sess *session, userID uint64) (*output.Result, error) {
+	if a.friends == nil {
+		return nil, ErrNoFriendsFetcher
+	}
+
+	friendIDs, err := a.friends.FetchIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := sess.checkMany(ctx, common.CheckTypeFriends, friendIDs)
+	if results == nil {
+		return nil, fmt.Errorf("failed to check friends: %w", err)
+	}
+	if err != nil {
+		err = fmt.Errorf("friends check stopped: %w", err)
+	}
+
+	flagged := make([]common.FriendResult, 0)
+	for i, result := range results {
+		friendID := friendIDs[i]
+		if result.Found {
+			flagged = append(flagged, common.FriendResult{
+				ID:         friendID,
+				Found:      true,
+				Status:     result.Status,
+				Reason:     result.Reason,
+				Confidence: result.Confidence,
+			})
+		}
+	}
+
+	return output.NewFriendsResult(userID, sess.config, sess.storageType, flagged, len(results), len(friendIDs)), err
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func TestApp_Check(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "first; second", confidence: 0.95}},
+		[]testRecord{{id: 2, status: "flagged", reason: "group reason", confidence: 0.5}},
+	)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "Flagged user",
+			args:       []string{"check", "user", "1", "--export-dir", dir, "--storage", "csv"},
+			wantCode:   ExitFlagged,
+			wantStdout: "User ID 1 was FOUND",
+		},
+		{
+			name:       "Clean user",
+			args:       []string{"check", "--export-dir", dir, "--storage", "csv", "user", "3"},
+			wantCode:   ExitClean,
+			wantStdout: "User ID 3 was NOT FOUND",
+		},
+		{
+			name:       "Flagged group",
+			args:       []string{"check", "group", "2", "--export-dir", dir, "--storage", "csv"},
+			wantCode:   ExitFlagged,
+			wantStdout: "Status: flagged",
+		},
+		{
+			name:       "Invalid check type",
+			args:       []string{"check", "place", "1", "--export-dir", dir, "--storage", "csv"},
+			wantCode:   ExitError,
+			wantStderr: "invalid check type",
+		},
+		{
+			name:       "Invalid ID",
+			args:       []string{"check", "user", "abc", "--export-dir", dir, "--storage", "csv"},
+			wantCode:   ExitError,
+			wantStderr: "invalid ID format",
+		},
+		{
+			name:       "Missing export directory",
+			args:       []string{"check", "user", "1", "--storage", "csv"},
+			wantCode:   ExitError,
+			wantStderr: "export directory is required",
+		},
+		{
+			name:       "Missing storage files",
+			args:       []string{"check", "user", "1", "--export-dir", dir, "--storage", "sqlite"},
+			wantCode:   ExitError,
+			wantStderr: "invalid export directory",
+		},
+
+		{
+			name:       "JSON output",
+			args:       []string{"check", "user", "1", "--export-dir", dir, "--storage", "csv", "--output", "json"},
+			wantCode:   ExitFlagged,
+			wantStdout: `"storageType": "csv"`,
+		},
+		{
+			name:       "Invalid output format",
+			args:       []string{"check", "user", "1", "--export-dir", dir, "--storage", "csv", "--output", "xml"},
+			wantCode:   ExitError,
+			wantStderr: "unsupported output format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(nil, tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stdout, tt.wantStdout)
+			assert.Contains(t, stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestApp_CheckFriends(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}},
+		nil,
+	)
+	args := []string{"check", "friends", "100", "--export-dir", dir, "--storage", "csv"}
+
+	code, stdout, _ := run(&fakeFriends{ids: []uint64{1, 2, 3}}, args...)
+	assert.Equal(t, ExitFlagged, code)
+	assert.Contains(t, stdout, "Friend 1 was FOUND")
+	assert.Contains(t, stdout, "1 flagged friends found out of 3 total friends")
+
+	code, stdout, _ = run(&fakeFriends{ids: []uint64{2, 3}}, args...)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "0 flagged friends found out of 2 total friends")
+
+	code, stdout, _ = run(&fakeFriends{ids: []uint64{1, 2}}, append(args, "--output", "ndjson")...)
+	assert.Equal(t, ExitFlagged, code)
+	assert.Contains(t, stdout, `"friends":{"total":2,"flagged":1`)
+
+	code, _, stderr := run(&fakeFriends{err: errors.New("api down")}, args...)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "api down")
+
+	code, _, stderr = run(nil, args...)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, ErrNoFriendsFetcher.Error())
+}
+
+func TestApp_CheckFriends_Stopped(t *testing.T) {
+	dir := setupExport(t, []testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}}, nil)
+	args := []string{"check", "friends", "100", "--export-dir", dir, "--storage", "csv"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The friends checked before stopping are still reported
+	code, stdout, stderr := runContext(ctx, "", &fakeFriends{ids: []uint64{1, 2, 3}}, args...)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stdout, "Check stopped after 0 of 3 friends")
+	assert.Contains(t, stderr, "friends check stopped: context canceled")
+
+	code, stdout, _ = runContext(ctx, "", &fakeFriends{ids: []uint64{1, 2, 3}}, append(args, "--output", "json")...)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stdout, `"checked": 0`)
+}
+
+func TestApp_CheckStrict(t *testing.T) {
+	dir := setupExport(t, nil, nil)
+
+	// SQLite export without an index on the hash column
+	conn, err := sqlite.OpenConn(filepath.Join(dir, "users.db"), sqlite.OpenCreate|sqlite.OpenReadWrite)
+	require.NoError(t, err)
+	require.NoError(t, sqlitex.ExecScript(conn, "CREATE TABLE users (hash TEXT, status TEXT, reason TEXT, confidence REAL);"))
+	require.NoError(t, conn.Close())
+
+	code, stdout, stderr := run(nil, "check", "user", "1", "--export-dir", dir)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "User ID 1 was NOT FOUND")
+	assert.Contains(t, stderr, "Warning: hash column has no index or unique constraint in users.db")
+
+	code, _, stderr = run(nil, "check", "user", "1", "--export-dir", dir, "--strict")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "hash column has no index or unique constraint in users.db")
+
+	// Other storage types are unaffected
+	code, _, stderr = run(nil, "check", "user", "1", "--export-dir", dir, "--storage", "csv", "--strict")
+	assert.Equal(t, ExitClean, code)
+	assert.Empty(t, stderr)
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Exit codes returned by Run.
+const (
+	// ExitClean indicates that every checked ID was clean.
+	ExitClean = 0
+	// ExitFlagged indicates that at least one checked ID was flagged, or that verification found problems.
+	ExitFlagged = 1
+	// ExitError indicates that the command failed.
+	ExitError = 2
+)
+
+var (
+	ErrUnknownCommand   = errors.New("unknown command")
+	ErrInvalidArguments = errors.New("invalid arguments")
+	ErrInvalidCheckType = errors.New("invalid check type")
+	ErrMissingExportDir = errors.New("an export directory is required (use --export-dir)")
+	ErrNoFriendsFetcher = errors.New("friends checks are not available")
+)
+
+// FriendsFetcher retrieves the friend IDs of a user.
+type FriendsFetcher interface {
+	FetchIDs(ctx context.Context, userID uint64) ([]uint64, error)
+}
+
+// App runs the non-interactive commands of Rotten.
+type App struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	friends FriendsFetcher
+	exports ExportsSource
+}
+
+// New creates a new App instance.
+func New(stdin io.Reader, stdout, stderr io.Writer, friends FriendsFetcher, exports ExportsSource) *App {
+	return &App{
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		friends: friends,
+		exports: exports,
+	}
+}
+
+// Run executes the command given by args and returns the process exit code.
+// Cancelling the context stops long-running checks.
+func (a *App) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		a.printUsage()
+		return ExitError
+	}
+
+	switch args[0] {
+	case "check":
+		return a.runCheck(ctx, args[1:])
+	case "batch":
+		return a.runBatch(ctx, args[1:])
+	case "prefs":
+		return a.runPrefs(args[1:])
+	case "exports":
+		return a.runExports(ctx, args[1:])
+	case "verify":
+		return a.runVerify(args[1:])
+	case "stats":
+		return a.runStats(args[1:])
+	case "hash":
+		return a.runHash(ctx, args[1:])
+	case "bloom":
+		return a.runBloom(args[1:])
+	case "cdb":
+		return a.runCDB(args[1:])
+	case "serve":
+		return a.runServe(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		a.printUsage()
+		return ExitClean
+	default:
+		a.printUsage()
+		return a.fail(fmt.Errorf("%w: %s", ErrUnknownCommand, args[0]))
+	}
+}
+
+// printUsage prints the list of available commands.
+func (a *App) printUsage() {
+	fmt.Fprintln(a.stderr, `Usage: rotten [command] [flags]
+
+Run without a command to start the interactive interface. Its flags preselect menu values:
+  --check-type <type>               Check type to start with (user, group, friends) [$ROTTEN_CHECK_TYPE]
+  --export-dir <dir>                Export directory to use [$ROTTEN_EXPORT_DIR]
+  --storage <type>                  Storage type to use, or auto [$ROTTEN_STORAGE]
+  --strict                          Refuse exports that would make every lookup slow [$ROTTEN_STRICT]
+
+Commands:
+  check <user|group|friends> <id>   Check an ID against an export
+  check <user|group> --stdin        Check IDs read line by line from stdin
+  batch --input <file>              Check a list of IDs and write a report
+  prefs [show|reset]                View or reset the saved preferences
+  exports <command>                 List, download, install and remove exports
+  verify <export-dir>               Check the integrity of every file in an export
+  stats <export-dir>                Summarize the statuses, confidences and reasons in an export
+  hash <id>                         Print the hash an export uses for an ID
+  bloom <export-dir>                Generate bloom filters that speed up lookups of clean IDs
+  cdb <export-dir>                  Build constant database files for instant lookups in large exports
+  serve <export-dir>                Answer lookups of an export over HTTP for remote storage`)
+}
+
+// fail prints the error and returns the error exit code.
+func (a *App) fail(err error) int {
+	fmt.Fprintf(a.stderr, "Error: %v\n", err)
+	return ExitError
+}
+
+// newFlagSet creates a flag set that prints the usage line and flag defaults to stderr.
+func (a *App) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(a.stderr, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and returns the positional arguments.
+// If parsing stops because of help or an invalid flag, ok is false and code is the exit code to return.
+func (a *App) parseFlags(fs *flag.FlagSet, args []string) ([]string, int, bool) {
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil, ExitClean, false
+	}
+	if err != nil {
+		return nil, ExitError, false
+	}
+	return positional, 0, true
+}
+
+// parseArgs parses flags that may appear before, between or after positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parseCheckType converts a command line argument to a check type.
+func parseCheckType(s string) (common.CheckType, error) {
+	switch checkType := common.CheckType(strings.ToLower(s)); checkType {
+	case common.CheckTypeUser, common.CheckTypeGroup, common.CheckTypeFriends:
+		return checkType, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidCheckType, s)
+	}
+}
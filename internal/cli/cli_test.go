@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/robalyx/rotten/internal/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSalt = "test_salt"
+
+// testRecord is a flagged ID written to a test export.
+type testRecord struct {
+	id         uint64
+	status     string
+	reason     string
+	confidence float64
+}
+
+// setupExport creates a CSV export using SHA256 hashing with the given user and group records.
+func setupExport(t *testing.T, users, groups []testRecord) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := &config.Config{
+		EngineVersion: "1.0.0",
+		ExportVersion: "1.0.0",
+		Salt:          testSalt,
+		HashType:      string(hasher.HashTypeSHA256),
+		Iterations:    1,
+	}
+	require.NoError(t, cfg.Save(dir))
+
+	files := map[string][]testRecord{
+		"users.csv":  users,
+		"groups.csv": groups,
+	}
+	for filename, records := range files {
+		var sb strings.Builder
+		sb.WriteString("hash,status,reason,confidence\n")
+		for _, record := range records {
+			hash := hasher.HashID(record.id, testSalt, hasher.HashTypeSHA256, 1, 0)
+			fmt.Fprintf(&sb, "%s,%s,%s,%g\n", hash, record.status, record.reason, record.confidence)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(sb.String()), 0o600))
+	}
+
+	return dir
+}
+
+// fakeFriends returns a fixed list of friends for every user.
+type fakeFriends struct {
+	ids []uint64
+	err error
+}
+
+func (f *fakeFriends) FetchIDs(_ context.Context, _ uint64) ([]uint64, error) {
+	return f.ids, f.err
+}
+
+// run executes the app with the given arguments and returns the exit code and output.
+func run(friends FriendsFetcher, args ...string) (int, string, string) {
+	return runWithInput("", friends, args...)
+}
+
+// runWithInput executes the app with the given stdin contents and arguments.
+func runWithInput(stdin string, friends FriendsFetcher, args ...string) (int, string, string) {
+	return runContext(context.Background(), stdin, friends, args...)
+}
+
+// runContext executes the app with the given context, stdin contents and arguments.
+func runContext(ctx context.Context, stdin string, friends FriendsFetcher, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := New(strings.NewReader(stdin), &stdout, &stderr, friends, nil).Run(ctx, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestApp_Run(t *testing.T) {
+	code, _, stderr := run(nil)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "Usage:")
+
+	for _, help := range []string{"help", "-h", "--help"} {
+		code, _, stderr = run(nil, help)
+		assert.Equal(t, ExitClean, code, help)
+		assert.Contains(t, stderr, "check <user|group|friends>", help)
+		assert.Contains(t, stderr, "--export-dir", help)
+	}
+
+	code, _, stderr = run(nil, "unknown")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "unknown command")
+}
@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/robalyx/rotten/internal/exports"
+	"github.com/robalyx/rotten/internal/version"
+)
+
+var (
+	ErrReleaseNotFound    = errors.New("no compatible export found with tag")
+	ErrExportNotInstalled = errors.New("no installed export found with name")
+	ErrAmbiguousExport    = errors.New("name matches more than one installed export")
+	ErrDestinationExists  = errors.New("destination already exists (use --force to replace it)")
+	ErrNoExportsSource    = errors.New("official exports are not available")
+	ErrRemoveBaseDir      = errors.New("refusing to remove the search directory itself")
+	ErrNotConfirmed       = errors.New("removal not confirmed (use --yes to skip the prompt)")
+)
+
+// ExportsSource lists and downloads official exports.
+type ExportsSource interface {
+	GetAvailableExports(ctx context.Context) ([]*exports.Release, error)
+	DownloadExport(ctx context.Context, release *exports.Release, destDir string) error
+}
+
+const exportsUsage = `Usage: rotten exports <command>
+
+Commands:
+  list                    List compatible official exports
+  download <tag>          Download an official export
+  installed               List exports found on disk
+  remove <name>           Remove the files of an installed export`
+
+// runExports handles the exports command.
+func (a *App) runExports(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(a.stderr, exportsUsage)
+		return ExitError
+	}
+
+	switch args[0] {
+	case "list":
+		return a.runExportsList(ctx, args[1:])
+	case "download":
+		return a.runExportsDownload(ctx, args[1:])
+	case "installed":
+		return a.runExportsInstalled(args[1:])
+	case "remove":
+		return a.runExportsRemove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprintln(a.stderr, exportsUsage)
+		return ExitClean
+	default:
+		fmt.Fprintln(a.stderr, exportsUsage)
+		return a.fail(fmt.Errorf("%w: exports %s", ErrUnknownCommand, args[0]))
+	}
+}
+
+// runExportsList lists the official exports compatible with this version of Rotten.
+func (a *App) runExportsList(ctx context.Context, args []string) int {
+	if len(args) != 0 {
+		return a.fail(fmt.Errorf("%w: usage: rotten exports list", ErrInvalidArguments))
+	}
+
+	releases, err := a.fetchReleases(ctx)
+	if err != nil {
+		return a.fail(err)
+	}
+	if len(releases) == 0 {
+		fmt.Fprintln(a.stdout, "No compatible exports available")
+		return ExitClean
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tNAME\tENGINE VERSION")
+	for _, release := range releases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", release.TagName, release.Name, version.ExtractFromNotes(release.Body))
+	}
+	if err := tw.Flush(); err != nil {
+		return a.fail(err)
+	}
+
+	return ExitClean
+}
+
+// runExportsDownload downloads an official export by its tag.
+func (a *App) runExportsDownload(ctx context.Context, args []string) int {
+	fs := a.newFlagSet("exports download", "Usage: rotten exports download <tag> [flags]")
+	dest := fs.String("dest", "", "directory to download the export to (default exports/<tag>)")
+	force := fs.Bool("force", false, "replace the destination if it already exists")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected a release tag", ErrInvalidArguments))
+	}
+	tag := positional[0]
+
+	if *dest == "" {
+		*dest = filepath.Join("exports", tag)
+	}
+
+	// Downloading replaces the destination, so never overwrite an existing file or directory by accident
+	if _, err := os.Lstat(*dest); err == nil && !*force {
+		return a.fail(fmt.Errorf("%w: %s", ErrDestinationExists, *dest))
+	}
+
+	releases, err := a.fetchReleases(ctx)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	index := slices.IndexFunc(releases, func(r *exports.Release) bool { return r.TagName == tag })
+	if index < 0 {
+		return a.fail(fmt.Errorf("%w: %s", ErrReleaseNotFound, tag))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	fmt.Fprintf(a.stderr, "Downloading %s...\n", releases[index].Name)
+	if err := a.exports.DownloadExport(ctx, releases[index], *dest); err != nil {
+		return a.fail(err)
+	}
+
+	fmt.Fprintf(a.stdout, "Downloaded %s to %s\n", tag, *dest)
+	return ExitClean
+}
+
+// runExportsInstalled lists the exports found on disk.
+func (a *App) runExportsInstalled(args []string) int {
+	fs := a.newFlagSet("exports installed", "Usage: rotten exports installed [flags]")
+	baseDir := fs.String("dir", ".", "directory to search for exports")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return a.fail(ErrInvalidArguments)
+	}
+
+	validator := checker.NewValidator()
+	dirs, err := validator.GetExportDirs(*baseDir)
+	if err != nil {
+		return a.fail(err)
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintln(a.stdout, "No exports installed")
+		return ExitClean
+	}
+	slices.Sort(dirs)
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tEXPORT VERSION\tENGINE VERSION\tUSERS\tGROUPS")
+	for _, dir := range dirs {
+		exportVersion, engineVersion := "-", "-"
+		if cfg, err := config.Load(dir); err == nil {
+			exportVersion, engineVersion = cfg.ExportVersion, cfg.EngineVersion
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", dir, exportVersion, engineVersion,
+			joinStorageTypes(validator.GetStorageTypes(dir, common.CheckTypeUser)),
+			joinStorageTypes(validator.GetStorageTypes(dir, common.CheckTypeGroup)))
+	}
+	if err := tw.Flush(); err != nil {
+		return a.fail(err)
+	}
+
+	return ExitClean
+}
+
+// runExportsRemove removes an installed export by its path or directory name.
+// Only the known export files are deleted, and the directory is removed only if nothing else is left in it.
+func (a *App) runExportsRemove(args []string) int {
+	fs := a.newFlagSet("exports remove", "Usage: rotten exports remove <name> [flags]")
+	baseDir := fs.String("dir", ".", "directory to search for exports")
+	yes := fs.Bool("yes", false, "remove without asking for confirmation")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export name", ErrInvalidArguments))
+	}
+	name := filepath.Clean(positional[0])
+
+	// Only directories recognized as exports can be removed
+	validator := checker.NewValidator()
+	dirs, err := validator.GetExportDirs(*baseDir)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	var matches []string
+	for _, dir := range dirs {
+		if dir == name || filepath.Base(dir) == name {
+			matches = append(matches, dir)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return a.fail(fmt.Errorf("%w: %s", ErrExportNotInstalled, name))
+	case 1:
+	default:
+		slices.Sort(matches)
+		return a.fail(fmt.Errorf("%w: %s", ErrAmbiguousExport, strings.Join(matches, ", ")))
+	}
+
+	dir := matches[0]
+
+	if sameDir(dir, *baseDir) {
+		return a.fail(fmt.Errorf("%w: %s", ErrRemoveBaseDir, dir))
+	}
+
+	files := validator.ExportFiles(dir)
+	if !*yes && !a.confirm(fmt.Sprintf("Remove %d export files from %s?", len(files), dir)) {
+		return a.fail(ErrNotConfirmed)
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return a.fail(fmt.Errorf("failed to remove export: %w", err))
+		}
+	}
+
+	// Leave the directory in place if it holds anything besides the export
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		fmt.Fprintf(a.stdout, "Removed %s (kept the directory, which contains other files)\n", dir)
+		return ExitClean
+	}
+	if err := os.Remove(dir); err != nil {
+		return a.fail(fmt.Errorf("failed to remove export directory: %w", err))
+	}
+
+	fmt.Fprintf(a.stdout, "Removed %s\n", dir)
+	return ExitClean
+}
+
+// confirm asks the question on stderr and reports whether the answer read from stdin is yes.
+func (a *App) confirm(question string) bool {
+	if a.stdin == nil {
+		return false
+	}
+
+	fmt.Fprintf(a.stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(a.stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// sameDir reports whether the two paths refer to the same directory.
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// fetchReleases returns the compatible official exports.
+// A newer version of Rotten being available is reported as a warning.
+func (a *App) fetchReleases(ctx context.Context) ([]*exports.Release, error) {
+	if a.exports == nil {
+		return nil, ErrNoExportsSource
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	releases, err := a.exports.GetAvailableExports(ctx)
+	if errors.Is(err, exports.ErrNewerVersionAvailable) {
+		fmt.Fprintln(a.stderr, "Warning: a newer version of Rotten is available at https://github.com/robalyx/rotten/releases")
+		return releases, nil
+	}
+	return releases, err
+}
+
+// joinStorageTypes formats a list of storage types for display.
+func joinStorageTypes(storageTypes []common.StorageType) string {
+	if len(storageTypes) == 0 {
+		return "-"
+	}
+
+	names := make([]string, len(storageTypes))
+	for i, storageType := range storageTypes {
+		names[i] = string(storageType)
+	}
+	return strings.Join(names, ",")
+}
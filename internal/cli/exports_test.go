@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/exports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExports serves a fixed list of releases and writes a small export on download.
+type fakeExports struct {
+	releases   []*exports.Release
+	err        error
+	downloaded []string
+}
+
+func (f *fakeExports) GetAvailableExports(_ context.Context) ([]*exports.Release, error) {
+	return f.releases, f.err
+}
+
+func (f *fakeExports) DownloadExport(_ context.Context, release *exports.Release, destDir string) error {
+	f.downloaded = append(f.downloaded, release.TagName)
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destDir, "users.csv"), []byte("hash,status,reason,confidence\n"), 0o600)
+}
+
+func runExports(source ExportsSource, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := New(nil, &stdout, &stderr, nil, source).Run(context.Background(), append([]string{"exports"}, args...))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestApp_ExportsList(t *testing.T) {
+	source := &fakeExports{releases: []*exports.Release{
+		{TagName: "v1.0.0", Name: "Export 1", Body: "## Engine Version\n1.2.0"},
+	}}
+
+	code, stdout, _ := runExports(source, "list")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "TAG")
+	assert.Regexp(t, `v1\.0\.0\s+Export 1\s+1\.2\.0`, stdout)
+
+	source.err = exports.ErrNewerVersionAvailable
+	code, stdout, stderr := runExports(source, "list")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "v1.0.0")
+	assert.Contains(t, stderr, "newer version")
+
+	code, stdout, _ = runExports(&fakeExports{}, "list")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "No compatible exports")
+}
+
+func TestApp_ExportsDownload(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "official")
+	source := &fakeExports{releases: []*exports.Release{{TagName: "v1.0.0", Name: "Export 1"}}}
+
+	code, stdout, _ := runExports(source, "download", "v1.0.0", "--dest", dest)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "Downloaded v1.0.0")
+	assert.FileExists(t, filepath.Join(dest, "users.csv"))
+
+	// Existing destination is not replaced without --force
+	code, _, stderr := runExports(source, "download", "v1.0.0", "--dest", dest)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, ErrDestinationExists.Error())
+
+	code, _, _ = runExports(source, "download", "v1.0.0", "--dest", dest, "--force")
+	assert.Equal(t, ExitClean, code)
+	assert.Equal(t, []string{"v1.0.0", "v1.0.0"}, source.downloaded)
+
+	// Existing files are not replaced either
+	file := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("keep"), 0o600))
+	code, _, stderr = runExports(source, "download", "v1.0.0", "--dest", file)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, ErrDestinationExists.Error())
+	assert.FileExists(t, file)
+
+	code, _, stderr = runExports(source, "download", "v2.0.0", "--dest", filepath.Join(t.TempDir(), "v2"))
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, ErrReleaseNotFound.Error())
+}
+
+func TestApp_ExportsInstalledAndRemove(t *testing.T) {
+	baseDir := t.TempDir()
+	official := setupExport(t, nil, nil)
+	require.NoError(t, os.Rename(official, filepath.Join(baseDir, "official")))
+	other := filepath.Join(baseDir, "nested", "other")
+	require.NoError(t, os.MkdirAll(other, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "groups.db"), nil, 0o600))
+
+	code, stdout, _ := runExports(nil, "installed", "--dir", baseDir)
+	assert.Equal(t, ExitClean, code)
+	assert.Regexp(t, `official\s+1\.0\.0\s+1\.0\.0\s+csv\s+csv`, stdout)
+	assert.Regexp(t, `other\s+-\s+-\s+-\s+sqlite`, stdout)
+
+	code, _, stderr := runExports(nil, "remove", "missing", "--dir", baseDir)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, ErrExportNotInstalled.Error())
+
+	code, stdout, _ = runExports(nil, "remove", "other", "--dir", baseDir, "--yes")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "Removed")
+	assert.NoDirExists(t, other)
+	assert.DirExists(t, filepath.Join(baseDir, "official"))
+
+	code, stdout, _ = runExports(nil, "installed", "--dir", t.TempDir())
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "No exports installed")
+}
+
+func TestApp_ExportsRemoveKeepsOtherFiles(t *testing.T) {
+	baseDir := t.TempDir()
+	home := filepath.Join(baseDir, "home")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "users.csv"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "users.bloom"), nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "docs", "important.txt"), []byte("keep"), 0o600))
+
+	// Removal needs confirmation
+	var stdout, stderr bytes.Buffer
+	app := New(strings.NewReader("n\n"), &stdout, &stderr, nil, nil)
+	code := app.Run(context.Background(), []string{"exports", "remove", "home", "--dir", baseDir})
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), ErrNotConfirmed.Error())
+	assert.FileExists(t, filepath.Join(home, "users.csv"))
+
+	stdout.Reset()
+	app = New(strings.NewReader("y\n"), &stdout, &stderr, nil, nil)
+	code = app.Run(context.Background(), []string{"exports", "remove", "home", "--dir", baseDir})
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout.String(), "kept the directory")
+	assert.NoFileExists(t, filepath.Join(home, "users.csv"))
+	assert.NoFileExists(t, filepath.Join(home, "users.bloom"))
+	assert.FileExists(t, filepath.Join(home, "docs", "important.txt"))
+
+	// The search directory itself is never removed
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "groups.db"), nil, 0o600))
+	code, _, errOut := runExports(nil, "remove", baseDir, "--dir", baseDir, "--yes")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, errOut, ErrRemoveBaseDir.Error())
+	assert.FileExists(t, filepath.Join(baseDir, "groups.db"))
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/robalyx/rotten/internal/hasher"
+)
+
+// runHash handles the hash command.
+func (a *App) runHash(ctx context.Context, args []string) int {
+	fs := a.newFlagSet("hash", "Usage: rotten hash <id> --export-dir <dir> [--lookup]\n"+
+		"       rotten hash <id> --salt <salt> --hash-type <argon2id|sha256> --iterations <n> [--memory <mb>]")
+	exportDir := fs.String("export-dir", "", "export directory to read the hash parameters from")
+	salt := fs.String("salt", "", "salt used for hashing")
+	hashType := fs.String("hash-type", string(hasher.HashTypeArgon2id), "hash algorithm (argon2id, sha256)")
+	iterations := uint32Flag(fs, "iterations", "number of hashing iterations")
+	memory := uint32Flag(fs, "memory", "memory for argon2id in MB")
+	lookup := fs.Bool("lookup", false, "with --export-dir, show whether the hash exists in each storage file")
+	checkTypeFlag := fs.String("type", string(common.CheckTypeUser), "with --lookup, type of the ID (user, group)")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an ID", ErrInvalidArguments))
+	}
+	id, err := strconv.ParseUint(positional[0], 10, 64)
+	if err != nil {
+		return a.fail(fmt.Errorf("invalid ID format: %w", err))
+	}
+
+	// Hash parameters come from either the export or the flags
+	explicit := isFlagSet(fs, "salt") || isFlagSet(fs, "hash-type") || isFlagSet(fs, "iterations") || isFlagSet(fs, "memory")
+	var cfg *config.Config
+	switch {
+	case *exportDir != "" && explicit:
+		return a.fail(fmt.Errorf("%w: use either --export-dir or explicit hash parameters", ErrInvalidArguments))
+	case *exportDir != "":
+		cfg, err = config.LoadOrCreate(*exportDir)
+		if err != nil {
+			return a.fail(fmt.Errorf("failed to load configuration: %w", err))
+		}
+	default:
+		cfg = &config.Config{HashType: *hashType, Salt: *salt, Iterations: *iterations, Memory: *memory}
+		if err := validateHashParams(cfg); err != nil {
+			return a.fail(err)
+		}
+	}
+	if *lookup && *exportDir == "" {
+		return a.fail(fmt.Errorf("%w: --lookup requires --export-dir", ErrInvalidArguments))
+	}
+
+	hash := hasher.HashID(id, cfg.Salt, hasher.HashType(cfg.HashType), cfg.Iterations, cfg.Memory)
+	fmt.Fprintln(a.stdout, hash)
+
+	if !*lookup {
+		return ExitClean
+	}
+
+	checkType, err := parseCheckType(*checkTypeFlag)
+	if err != nil {
+		return a.fail(err)
+	}
+	return a.lookupHash(ctx, *exportDir, lookupType(checkType), hash)
+}
+
+// lookupHash reports whether the hash exists in each storage file of the export.
+func (a *App) lookupHash(ctx context.Context, dir string, checkType common.CheckType, hash string) int {
+	validator := checker.NewValidator()
+	storageTypes := validator.GetStorageTypes(dir, checkType)
+	if len(storageTypes) == 0 {
+		return a.fail(fmt.Errorf("%w: no %s files found in %s", ErrInvalidArguments, checkType, dir))
+	}
+
+	found := false
+	tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	for _, storageType := range storageTypes {
+		c, err := checker.New(dir, storageType)
+		if err != nil {
+			return a.fail(err)
+		}
+
+		result, err := c.Check(ctx, checkType, hash)
+		c.Close()
+		switch {
+		case err != nil:
+			fmt.Fprintf(tw, "%s\terror: %v\n", storageType, err)
+		case result.Found:
+			found = true
+			fmt.Fprintf(tw, "%s\tfound\t%s\t%.2f\n", storageType, result.Status, result.Confidence)
+		default:
+			fmt.Fprintf(tw, "%s\tnot found\n", storageType)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return a.fail(err)
+	}
+
+	if found {
+		return ExitFlagged
+	}
+	return ExitClean
+}
+
+// uint32Flag defines a flag holding an unsigned 32-bit integer.
+func uint32Flag(fs *flag.FlagSet, name, usage string) *uint32 {
+	var value uint32
+	fs.Func(name, usage, func(s string) error {
+		v, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		value = uint32(v)
+		return nil
+	})
+	return &value
+}
+
+// validateHashParams checks that explicit hash parameters can produce a hash.
+func validateHashParams(cfg *config.Config) error {
+	if cfg.Salt == "" {
+		return fmt.Errorf("%w: --salt is required without --export-dir", ErrInvalidArguments)
+	}
+	if cfg.Iterations == 0 {
+		return fmt.Errorf("%w: --iterations must be at least 1", ErrInvalidArguments)
+	}
+
+	switch hasher.HashType(cfg.HashType) {
+	case hasher.HashTypeArgon2id:
+		if cfg.Memory == 0 {
+			return fmt.Errorf("%w: --memory is required for argon2id", ErrInvalidArguments)
+		}
+	case hasher.HashTypeSHA256:
+	default:
+		return fmt.Errorf("%w: %s", config.ErrInvalidHash, cfg.HashType)
+	}
+	return nil
+}
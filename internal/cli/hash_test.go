@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/hasher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Hash(t *testing.T) {
+	dir := setupExport(t, []testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}}, nil)
+	want := hasher.HashID(1, "test_salt", hasher.HashTypeSHA256, 1, 0)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+		wantErr  string
+	}{
+		{
+			name:     "From export",
+			args:     []string{"hash", "1", "--export-dir", dir},
+			wantCode: ExitClean,
+			wantOut:  want + "\n",
+		},
+		{
+			name:     "From explicit parameters",
+			args:     []string{"hash", "1", "--salt", "test_salt", "--hash-type", "sha256", "--iterations", "1"},
+			wantCode: ExitClean,
+			wantOut:  want + "\n",
+		},
+		{
+			name:     "Lookup found",
+			args:     []string{"hash", "1", "--export-dir", dir, "--lookup"},
+			wantCode: ExitFlagged,
+			wantOut:  want + "\ncsv  found  confirmed  0.95\n",
+		},
+		{
+			name:     "Lookup not found",
+			args:     []string{"hash", "2", "--export-dir", dir, "--lookup"},
+			wantCode: ExitClean,
+			wantOut:  "csv  not found",
+		},
+		{
+			name:     "Both export and parameters",
+			args:     []string{"hash", "1", "--export-dir", dir, "--salt", "x"},
+			wantCode: ExitError,
+			wantErr:  "use either --export-dir or explicit hash parameters",
+		},
+		{
+			name:     "Missing memory for argon2id",
+			args:     []string{"hash", "1", "--salt", "x", "--iterations", "1"},
+			wantCode: ExitError,
+			wantErr:  "--memory is required for argon2id",
+		},
+		{
+			name:     "Lookup without export",
+			args:     []string{"hash", "1", "--salt", "x", "--hash-type", "sha256", "--iterations", "1", "--lookup"},
+			wantCode: ExitError,
+			wantErr:  "--lookup requires --export-dir",
+		},
+		{
+			name:     "Invalid iterations",
+			args:     []string{"hash", "1", "--salt", "x", "--iterations", "-1"},
+			wantCode: ExitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(nil, tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			if tt.wantOut != "" {
+				assert.True(t, strings.Contains(stdout, tt.wantOut), stdout)
+			}
+			assert.Contains(t, stderr, tt.wantErr)
+		})
+	}
+}
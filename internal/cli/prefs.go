@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/preferences"
+)
+
+// runPrefs handles the prefs command.
+func (a *App) runPrefs(args []string) int {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if len(args) > 1 {
+		return a.fail(fmt.Errorf("%w: usage: rotten prefs [show|reset]", ErrInvalidArguments))
+	}
+
+	path, err := preferences.DefaultPath()
+	if err != nil {
+		return a.fail(err)
+	}
+
+	switch action {
+	case "show":
+		prefs, err := preferences.Load(path)
+		if err != nil {
+			return a.fail(err)
+		}
+
+		data, err := json.MarshalIndent(prefs, "", "  ")
+		if err != nil {
+			return a.fail(fmt.Errorf("failed to marshal preferences: %w", err))
+		}
+
+		fmt.Fprintf(a.stdout, "Preferences file: %s\n%s\n", path, data)
+	case "reset":
+		if err := preferences.Reset(path); err != nil {
+			return a.fail(err)
+		}
+		fmt.Fprintln(a.stdout, "Preferences have been reset")
+	default:
+		return a.fail(fmt.Errorf("%w: usage: rotten prefs [show|reset]", ErrInvalidArguments))
+	}
+
+	return ExitClean
+}
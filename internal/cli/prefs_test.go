@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/preferences"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Prefs(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("AppData", configDir)
+	t.Setenv("HOME", configDir)
+
+	path, err := preferences.DefaultPath()
+	require.NoError(t, err)
+	prefs := &preferences.Preferences{CheckType: common.CheckTypeUser, ExportDir: "/exports/official"}
+	require.NoError(t, prefs.Save(path))
+
+	code, stdout, _ := run(nil, "prefs")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, path)
+	assert.Contains(t, stdout, `"exportDir": "/exports/official"`)
+
+	code, stdout, _ = run(nil, "prefs", "reset")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "reset")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	code, _, _ = run(nil, "prefs", "delete")
+	assert.Equal(t, ExitError, code)
+}
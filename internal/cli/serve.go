@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/remote"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+const (
+	// serveReadHeaderTimeout limits how long a client may take to send its request headers.
+	serveReadHeaderTimeout = 10 * time.Second
+
+	// serveShutdownTimeout is how long requests in flight are given to finish when the server stops.
+	serveShutdownTimeout = 5 * time.Second
+)
+
+// runServe handles the serve command.
+func (a *App) runServe(ctx context.Context, args []string) int {
+	fs := a.newFlagSet("serve", "Usage: rotten serve <export-dir> [flags]")
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	storage := fs.String("storage", "", "storage type to read the records from (default: the first one found)")
+	minPrefixLength := fs.Int("min-prefix-length", remote.DefaultMinPrefixLength, "shortest hash prefix answered by range lookups")
+	authHeader := fs.String("auth-header", "", "header clients must send with every request, e.g. \"Authorization: Bearer token\"")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export directory", ErrInvalidArguments))
+	}
+	dir := positional[0]
+
+	// Serve the check types the export has files for, which must share a storage type
+	validator := checker.NewValidator()
+	storageType := common.StorageType(strings.ToLower(*storage))
+	checkTypes, storageType, err := serveCheckTypes(validator, dir, storageType)
+	if err != nil {
+		return a.fail(err)
+	}
+
+	// Load the records into memory, after which the storage files are no longer needed
+	c, err := checker.New(dir, storageType)
+	if err != nil {
+		return a.fail(err)
+	}
+	server, err := remote.NewServer(ctx, c, remote.ServerOptions{
+		MinPrefixLength: *minPrefixLength,
+		AuthHeader:      *authHeader,
+		CheckTypes:      checkTypes,
+	})
+	c.Close()
+	if err != nil {
+		return a.fail(err)
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", *addr)
+	if err != nil {
+		return a.fail(fmt.Errorf("failed to listen: %w", err))
+	}
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: serveReadHeaderTimeout}
+
+	fmt.Fprintf(a.stderr, "Serving %d user and %d group records from %s on http://%s\n",
+		server.Count(common.CheckTypeUser), server.Count(common.CheckTypeGroup), storageType, listener.Addr())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	// Serve until interrupted
+	select {
+	case err := <-serveErr:
+		return a.fail(fmt.Errorf("server stopped: %w", err))
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), serveShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return a.fail(fmt.Errorf("failed to stop server: %w", err))
+	}
+	return ExitClean
+}
+
+// serveCheckTypes returns the check types with files of the storage type in the export directory.
+// If storageType is empty, the first storage type that every check type in the export has files for is used.
+func serveCheckTypes(
+	validator *checker.Validator, dir string, storageType common.StorageType,
+) ([]common.CheckType, common.StorageType, error) {
+	// Exports may contain only one check type
+	var checkTypes []common.CheckType
+	var storageTypes [][]common.StorageType
+	for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+		if available := validator.GetStorageTypes(dir, checkType); len(available) > 0 {
+			checkTypes = append(checkTypes, checkType)
+			storageTypes = append(storageTypes, available)
+		}
+	}
+	if len(checkTypes) == 0 {
+		return nil, "", fmt.Errorf("%w: no storage files found in %s", ErrInvalidArguments, dir)
+	}
+
+	if storageType == "" {
+		for _, candidate := range storageTypes[0] {
+			if !slices.ContainsFunc(storageTypes[1:], func(available []common.StorageType) bool {
+				return !slices.Contains(available, candidate)
+			}) {
+				storageType = candidate
+				break
+			}
+		}
+		if storageType == "" {
+			return nil, "", fmt.Errorf("%w: the user and group files of %s have no storage type in common, "+
+				"choose one with --storage", ErrInvalidArguments, dir)
+		}
+	}
+
+	for _, checkType := range checkTypes {
+		if err := validator.ValidateExportDir(dir, checkType, storageType); err != nil {
+			return nil, "", fmt.Errorf("invalid export directory: %w", err)
+		}
+	}
+	return checkTypes, storageType, nil
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer that can be written and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestApp_Serve(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}},
+		[]testRecord{{id: 2, status: "flagged", reason: "reason", confidence: 0.5}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, stderr, done := startServe(t, ctx, dir, "--auth-header", "X-Api-Key: secret")
+	assert.Contains(t, stderr.String(), "Serving 1 user and 1 group records from csv")
+
+	// Clients only read the configuration from the export directory
+	remoteArgs := []string{"--export-dir", dir, "--storage", "remote", "--remote-url", url, "--remote-auth-header", "X-Api-Key: secret"}
+
+	code, stdout, errOut := run(nil, append([]string{"check", "user", "1", "--prefix-length", "5"}, remoteArgs...)...)
+	assert.Equal(t, ExitFlagged, code, errOut)
+	assert.Contains(t, stdout, "User ID 1 was FOUND")
+
+	code, stdout, errOut = run(nil, append([]string{"check", "group", "3", "--prefix-length", "5"}, remoteArgs...)...)
+	assert.Equal(t, ExitClean, code, errOut)
+	assert.Contains(t, stdout, "Group ID 3 was NOT FOUND")
+
+	code, stdout, errOut = run(nil, append([]string{"check", "group", "2"}, remoteArgs...)...)
+	assert.Equal(t, ExitFlagged, code, errOut)
+	assert.Contains(t, stdout, "Group ID 2 was FOUND")
+
+	// A prefix shorter than the server allows is refused
+	code, _, errOut = run(nil, append([]string{"check", "user", "1", "--prefix-length", "2"}, remoteArgs...)...)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, errOut, "must be at least 5 characters")
+
+	cancel()
+	select {
+	case code := <-done:
+		assert.Equal(t, ExitClean, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+
+	// Connection failures are reported as errors rather than clean results
+	code, stdout, errOut = run(nil, append([]string{"check", "user", "1"}, remoteArgs...)...)
+	assert.Equal(t, ExitError, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, errOut, "remote checker is unavailable")
+
+	code, _, errOut = run(nil, "serve", dir, "--storage", "sqlite")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, errOut, "invalid export directory")
+
+	code, _, _ = run(nil, "serve")
+	assert.Equal(t, ExitError, code)
+}
+
+func TestApp_ServeSingleCheckType(t *testing.T) {
+	dir := setupExport(t, nil, []testRecord{{id: 2, status: "flagged", reason: "reason", confidence: 0.5}})
+	require.NoError(t, os.Remove(filepath.Join(dir, "users.csv")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, stderr, _ := startServe(t, ctx, dir)
+	assert.Contains(t, stderr.String(), "Serving 0 user and 1 group records from csv")
+
+	remoteArgs := []string{"--export-dir", dir, "--storage", "remote", "--remote-url", url}
+
+	code, stdout, errOut := run(nil, append([]string{"check", "group", "2"}, remoteArgs...)...)
+	assert.Equal(t, ExitFlagged, code, errOut)
+	assert.Contains(t, stdout, "Group ID 2 was FOUND")
+
+	// The check type missing from the export has no records
+	code, stdout, errOut = run(nil, append([]string{"check", "user", "1"}, remoteArgs...)...)
+	assert.Equal(t, ExitClean, code, errOut)
+	assert.Contains(t, stdout, "User ID 1 was NOT FOUND")
+}
+
+// startServe serves the export on a free port until the context is cancelled and returns its URL,
+// the stderr of the command and a channel that receives its exit code.
+func startServe(t *testing.T, ctx context.Context, dir string, args ...string) (string, *syncBuffer, <-chan int) {
+	t.Helper()
+
+	stderr := &syncBuffer{}
+	done := make(chan int, 1)
+	go func() {
+		app := New(strings.NewReader(""), &bytes.Buffer{}, stderr, nil, nil)
+		done <- app.Run(ctx, append([]string{"serve", dir, "--addr", "127.0.0.1:0"}, args...))
+	}()
+
+	var url string
+	require.Eventually(t, func() bool {
+		match := regexp.MustCompile(`on (http://\S+)`).FindStringSubmatch(stderr.String())
+		if match != nil {
+			url = match[1]
+		}
+		return match != nil
+	}, 5*time.Second, 10*time.Millisecond, stderr.String())
+
+	return url, stderr, done
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/remote"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/robalyx/rotten/internal/hasher"
+	"github.com/robalyx/rotten/internal/output"
+)
+
+// session holds an export that is ready for lookups.
+type session struct {
+	dir         string
+	storageType common.StorageType
+	config      *config.Config
+	checker     checker.Checker
+}
+
+// checkerFlags registers the flags that configure how the export is opened, and returns a function
+// that converts them to checker options once the flags are parsed.
+func checkerFlags(fs *flag.FlagSet) func() checker.Options {
+	strict := fs.Bool("strict", false, "refuse exports that would make every lookup slow, such as unindexed SQLite databases")
+	remoteURL := fs.String("remote-url", "", "with --storage remote, URL of the server to look hashes up on")
+	remoteAuth := fs.String("remote-auth-header", "", "with --storage remote, header sent with every request, e.g. \"Authorization: Bearer token\"")
+	remoteTimeout := fs.Duration("remote-timeout", remote.DefaultTimeout, "with --storage remote, timeout of each request")
+	prefixLength := fs.Int("prefix-length", 0,
+		"with --storage remote, send only this many characters of each hash so the server cannot tell which ID was checked (0 sends full hashes)")
+
+	return func() checker.Options {
+		return checker.Options{
+			Strict: *strict,
+			Remote: remote.Config{
+				BaseURL:      *remoteURL,
+				Timeout:      *remoteTimeout,
+				AuthHeader:   *remoteAuth,
+				PrefixLength: *prefixLength,
+			},
+		}
+	}
+}
+
+// openSession validates the export directory, loads its configuration and creates a checker.
+// Remote storage only reads the configuration from the export directory, to hash IDs the same way
+// as the server. Problems that slow down lookups are printed as warnings. The session must be closed when done.
+func (a *App) openSession(
+	dir string, checkType common.CheckType, storageType common.StorageType, opts checker.Options,
+) (*session, error) {
+	if dir == "" {
+		return nil, ErrMissingExportDir
+	}
+
+	// Validate export directory
+	if storageType != common.StorageTypeRemote {
+		validator := checker.NewValidator()
+		if err := validator.ValidateExportDir(dir, lookupType(checkType), storageType); err != nil {
+			return nil, fmt.Errorf("invalid export directory: %w", err)
+		}
+	}
+
+	// Load configuration
+	cfg, err := config.LoadOrCreate(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Initialize checker
+	c, err := checker.NewWithOptions(dir, storageType, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range checker.Warnings(c) {
+		fmt.Fprintf(a.stderr, "Warning: %v\n", warning)
+	}
+
+	return &session{
+		dir:         dir,
+		storageType: storageType,
+		config:      cfg,
+		checker:     c,
+	}, nil
+}
+
+// close releases the export files held by the checker.
+func (s *session) close() error {
+	return s.checker.Close()
+}
+
+// hash converts an ID to the hash used by the export.
+func (s *session) hash(id uint64) string {
+	return hasher.HashID(id, s.config.Salt, hasher.HashType(s.config.HashType), s.config.Iterations, s.config.Memory)
+}
+
+// check hashes the ID and looks it up in the export.
+func (s *session) check(ctx context.Context, checkType common.CheckType, id uint64) (*common.CheckResult, error) {
+	return s.checker.Check(ctx, lookupType(checkType), s.hash(id))
+}
+
+// checkMany hashes the IDs and looks them all up in the export at once.
+// If the context is done, the results of the IDs checked so far are returned with the context error.
+func (s *session) checkMany(ctx context.Context, checkType common.CheckType, ids []uint64) ([]*common.CheckResult, error) {
+	return checker.CheckIDs(ctx, s.checker, lookupType(checkType), ids, s.hash)
+}
+
+// result wraps a check result with the export details.
+func (s *session) result(checkType common.CheckType, id uint64, result *common.CheckResult) *output.Result {
+	return output.NewResult(id, checkType, s.config, s.storageType, result)
+}
+
+// lookupType returns the check type whose export files are used for the given check type.
+// Friends checks look up user hashes.
+func lookupType(checkType common.CheckType) common.CheckType {
+	if checkType == common.CheckTypeFriends {
+		return common.CheckTypeUser
+	}
+	return checkType
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// histogramWidth is the width of the longest bar in the confidence histogram.
+const histogramWidth = 30
+
+// runStats handles the stats command.
+func (a *App) runStats(args []string) int {
+	fs := a.newFlagSet("stats", "Usage: rotten stats <export-dir> [flags]")
+	checkTypeFlag := fs.String("type", "", "only show stats for this check type (user, group)")
+	storage := fs.String("storage", string(common.StorageTypeSQLite),
+		"storage type (sqlite, binary, csv, jsonl, cdb, csv-indexed, binary-indexed)")
+	top := fs.Int("top", 10, "number of most frequent reasons to show")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export directory", ErrInvalidArguments))
+	}
+	dir := positional[0]
+	storageType := common.StorageType(strings.ToLower(*storage))
+
+	// Determine which check types to show
+	checkTypes := []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup}
+	if *checkTypeFlag != "" {
+		checkType, err := parseCheckType(*checkTypeFlag)
+		if err != nil {
+			return a.fail(err)
+		}
+		checkTypes = []common.CheckType{lookupType(checkType)}
+	}
+
+	validator := checker.NewValidator()
+	shown := 0
+	for _, checkType := range checkTypes {
+		if err := validator.ValidateExportDir(dir, checkType, storageType); err != nil {
+			if *checkTypeFlag != "" {
+				return a.fail(fmt.Errorf("invalid export directory: %w", err))
+			}
+			continue // Exports may contain only one check type
+		}
+
+		stats, err := checker.CollectStats(dir, checkType, storageType)
+		if err != nil {
+			return a.fail(err)
+		}
+
+		if shown > 0 {
+			fmt.Fprintln(a.stdout)
+		}
+		if err := writeStats(a.stdout, stats, *top); err != nil {
+			return a.fail(err)
+		}
+		shown++
+	}
+
+	if shown == 0 {
+		return a.fail(fmt.Errorf("%w: no %s files found in %s", ErrInvalidArguments, storageType, dir))
+	}
+	return ExitClean
+}
+
+// writeStats writes a readable summary of the stats.
+func writeStats(w io.Writer, stats *checker.Stats, top int) error {
+	checkType := string(stats.CheckType)
+	fmt.Fprintf(w, "%s records (%s)\n", strings.ToUpper(checkType[:1])+checkType[1:], stats.StorageType)
+	fmt.Fprintf(w, "Total hashes: %d\n", stats.Total)
+	if stats.Skipped > 0 {
+		fmt.Fprintf(w, "Corrupt records skipped: %d (run 'rotten verify' for details)\n", stats.Skipped)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	// Status breakdown
+	fmt.Fprintln(tw, "\nStatus:")
+	for _, status := range stats.Statuses() {
+		fmt.Fprintf(tw, "  %s\t%d\t%s\n", status.Value, status.Count, percent(status.Count, stats.Total))
+	}
+
+	// Confidence histogram
+	fmt.Fprintln(tw, "\nConfidence:")
+	peak := 0
+	for _, count := range stats.Confidence {
+		peak = max(peak, count)
+	}
+	for bucket, count := range stats.Confidence {
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("#", count*histogramWidth/peak)
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%s\n", checker.BucketLabel(bucket), count, bar)
+	}
+
+	// Most frequent reasons
+	if reasons := stats.TopReasons(top); len(reasons) > 0 {
+		fmt.Fprintln(tw, "\nTop reasons:")
+		for _, reason := range reasons {
+			fmt.Fprintf(tw, "  %d\t%s\n", reason.Count, reason.Value)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// percent formats part as a percentage of total.
+func percent(part, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Stats(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{
+			{id: 1, status: "confirmed", reason: "bad name; bad avatar", confidence: 0.95},
+			{id: 2, status: "confirmed", reason: "bad name", confidence: 0.9},
+			{id: 3, status: "flagged", reason: "bad avatar; bad name", confidence: 0.3},
+		},
+		[]testRecord{{id: 4, status: "flagged", reason: "bad description", confidence: 0.5}},
+	)
+
+	code, stdout, _ := run(nil, "stats", dir, "--storage", "csv")
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "User records (csv)\nTotal hashes: 3")
+	assert.Contains(t, stdout, "Group records (csv)\nTotal hashes: 1")
+	assert.Regexp(t, `confirmed +2 +66\.7%`, stdout)
+	assert.Regexp(t, `0\.9-1\.0 +2 +#{30}\n`, stdout)
+	assert.Regexp(t, `0\.3-0\.4 +1 +#{15}\n`, stdout)
+	assert.Regexp(t, `3 +bad name\n +2 +bad avatar\n`, stdout)
+
+	// Only one check type with a limited number of reasons
+	code, stdout, _ = run(nil, "stats", dir, "--storage", "csv", "--type", "user", "--top", "1")
+	assert.Equal(t, ExitClean, code)
+	assert.NotContains(t, stdout, "Group records")
+	assert.NotContains(t, stdout, "bad avatar\n")
+
+	code, _, stderr := run(nil, "stats", dir)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "no sqlite files found")
+}
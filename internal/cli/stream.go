@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/output"
+)
+
+// streamJob is an ID read from the input stream.
+type streamJob struct {
+	seq   int
+	line  int
+	id    uint64
+	err   error
+	fatal bool
+}
+
+// streamResult is the outcome of checking a streamed ID.
+type streamResult struct {
+	seq    int
+	line   int
+	result *output.Result
+	err    error
+	fatal  bool
+}
+
+// streamWindow is the number of IDs per worker that may be read ahead of the last written result.
+// It bounds the results held back while an earlier line is still being checked.
+const streamWindow = 4
+
+// checkStream reads IDs from r line by line and writes a result for each as soon as it is checked.
+// Results are written in input order unless unordered is set. Invalid lines are reported and skipped,
+// while lookup failures stop the stream.
+func (a *App) checkStream(
+	ctx context.Context, r io.Reader, sess *session, checkType common.CheckType, format output.Format, workers int, unordered bool,
+) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan streamJob, workers)
+	results := make(chan streamResult, workers)
+
+	// Each ID read takes a slot until its result is written, so reading pauses when the window is full
+	slots := make(chan struct{}, workers*streamWindow)
+	acquire := func() bool {
+		select {
+		case slots <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Read IDs from input
+	go func() {
+		defer close(jobs)
+
+		scanner := bufio.NewScanner(r)
+		seq := 0
+		for lineNum := 1; scanner.Scan(); lineNum++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			job := streamJob{seq: seq, line: lineNum}
+			job.id, job.err = strconv.ParseUint(line, 10, 64)
+			seq++
+
+			if !acquire() {
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && acquire() {
+			select {
+			case jobs <- streamJob{seq: seq, err: fmt.Errorf("failed to read input: %w", err), fatal: true}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	// Hash and check IDs using a single checker
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				res := streamResult{seq: job.seq, line: job.line, err: job.err, fatal: job.fatal}
+				if job.err == nil {
+					result, err := sess.check(ctx, checkType, job.id)
+					if err != nil {
+						res.err = fmt.Errorf("failed to check ID %d: %w", job.id, err)
+						res.fatal = true
+					} else {
+						res.result = sess.result(checkType, job.id, result)
+					}
+				}
+
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Write results as they complete
+	writer := output.NewListWriter(a.stdout, format)
+	pending := make(map[int]streamResult)
+	next := 0
+	hasInvalid := false
+	hasFlagged := false
+
+	emit := func(res streamResult) error {
+		<-slots
+		if res.fatal {
+			return res.err
+		}
+		if res.err != nil {
+			hasInvalid = true
+			fmt.Fprintf(a.stderr, "Error: invalid ID on line %d: %v\n", res.line, res.err)
+			return nil
+		}
+
+		if res.result.Flagged() {
+			hasFlagged = true
+		}
+		if err := writer.Write(res.result); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}
+
+	for res := range results {
+		if unordered {
+			if err := emit(res); err != nil {
+				return a.fail(err)
+			}
+			continue
+		}
+
+		// Hold results until all earlier lines have been written
+		pending[res.seq] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if err := emit(res); err != nil {
+				return a.fail(err)
+			}
+		}
+	}
+
+	// Stop if the stream was interrupted
+	if err := ctx.Err(); err != nil {
+		return a.fail(err)
+	}
+
+	switch {
+	case hasInvalid:
+		return ExitError
+	case hasFlagged:
+		return ExitFlagged
+	default:
+		return ExitClean
+	}
+}
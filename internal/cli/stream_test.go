@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/output"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_CheckStream(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{
+			{id: 1, status: "confirmed", reason: "reason", confidence: 0.95},
+			{id: 5, status: "flagged", reason: "reason", confidence: 0.5},
+		},
+		nil,
+	)
+	args := []string{"check", "user", "--stdin", "--export-dir", dir, "--storage", "csv"}
+
+	// Build a long input so results complete out of order across workers
+	var input strings.Builder
+	for i := range 200 {
+		input.WriteString(strings.Repeat(" ", i%3) + string(rune('0'+i%10)) + "\n")
+	}
+
+	t.Run("Ordered", func(t *testing.T) {
+		code, stdout, _ := runWithInput(input.String(), nil, append(args, "--workers", "8")...)
+		assert.Equal(t, ExitFlagged, code)
+
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		require.Len(t, lines, 200)
+		for i, line := range lines {
+			var result struct {
+				ID    uint64 `json:"id"`
+				Found bool   `json:"found"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(line), &result))
+			assert.Equal(t, uint64(i%10), result.ID)
+			assert.Equal(t, i%10 == 1 || i%10 == 5, result.Found)
+		}
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		code, stdout, _ := runWithInput(input.String(), nil, append(args, "--unordered")...)
+		assert.Equal(t, ExitFlagged, code)
+		assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 200)
+		assert.Equal(t, 40, strings.Count(stdout, `"found":true`))
+	})
+
+	t.Run("CSV output", func(t *testing.T) {
+		code, stdout, _ := runWithInput("2\n1\n", nil, append(args, "--output", "csv")...)
+		assert.Equal(t, ExitFlagged, code)
+		assert.Equal(t, "id,found,status,reason,confidence\n2,false,,,\n1,true,confirmed,reason,0.95\n", stdout)
+	})
+
+	t.Run("Clean", func(t *testing.T) {
+		code, _, _ := runWithInput("2\n3\n", nil, args...)
+		assert.Equal(t, ExitClean, code)
+	})
+
+	t.Run("Invalid lines are skipped", func(t *testing.T) {
+		code, stdout, stderr := runWithInput("2\nabc\n3\n", nil, args...)
+		assert.Equal(t, ExitError, code)
+		assert.Equal(t, 2, strings.Count(stdout, "\n"))
+		assert.Contains(t, stderr, "invalid ID on line 2")
+	})
+
+	t.Run("Table output not supported", func(t *testing.T) {
+		code, _, stderr := runWithInput("1\n", nil, append(args, "--output", "table")...)
+		assert.Equal(t, ExitError, code)
+		assert.Contains(t, stderr, "ndjson and csv output only")
+	})
+
+	t.Run("ID argument not allowed", func(t *testing.T) {
+		code, _, _ := runWithInput("1\n", nil, "check", "user", "1", "--stdin", "--export-dir", dir)
+		assert.Equal(t, ExitError, code)
+	})
+}
+
+// blockingChecker holds lookups of one hash until released, counting every lookup.
+type blockingChecker struct {
+	checker.Checker
+	blocked string
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (c *blockingChecker) Check(ctx context.Context, checkType common.CheckType, id string) (*common.CheckResult, error) {
+	c.calls.Add(1)
+	if id == c.blocked {
+		<-c.release
+	}
+	return c.Checker.Check(ctx, checkType, id)
+}
+
+func TestApp_CheckStream_Window(t *testing.T) {
+	dir := setupExport(t, []testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}}, nil)
+
+	var stdout, stderr bytes.Buffer
+	a := New(nil, &stdout, &stderr, nil, nil)
+	sess, err := a.openSession(dir, common.CheckTypeUser, common.StorageTypeCSV, checker.Options{})
+	require.NoError(t, err)
+	defer sess.close()
+
+	// Hold the first line, so that every later result has to wait for it
+	blocking := &blockingChecker{Checker: sess.checker, blocked: sess.hash(1), release: make(chan struct{})}
+	sess.checker = blocking
+
+	var input strings.Builder
+	for i := 1; i <= 1000; i++ {
+		input.WriteString(strconv.Itoa(i) + "\n")
+	}
+
+	const workers = 2
+	done := make(chan int)
+	go func() {
+		done <- a.checkStream(context.Background(), strings.NewReader(input.String()), sess,
+			common.CheckTypeUser, output.FormatNDJSON, workers, false)
+	}()
+
+	// Reading stops once the window is full instead of holding every later result
+	window := int64(workers * streamWindow)
+	require.Eventually(t, func() bool { return blocking.calls.Load() == window }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, window, blocking.calls.Load())
+	assert.Empty(t, stdout.String())
+
+	close(blocking.release)
+	assert.Equal(t, ExitFlagged, <-done)
+	assert.Equal(t, int64(1000), blocking.calls.Load())
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout.String()), "\n"), 1000)
+}
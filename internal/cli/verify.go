@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/robalyx/rotten/internal/checker"
+)
+
+// runVerify handles the verify command.
+func (a *App) runVerify(args []string) int {
+	fs := a.newFlagSet("verify", "Usage: rotten verify <export-dir>")
+
+	positional, code, ok := a.parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return a.fail(fmt.Errorf("%w: expected an export directory", ErrInvalidArguments))
+	}
+
+	report := checker.NewValidator().Verify(positional[0])
+
+	// Configuration
+	if report.ConfigErr != nil {
+		fmt.Fprintf(a.stdout, "Config: FAILED: %v\n", report.ConfigErr)
+	} else {
+		fmt.Fprintf(a.stdout, "Config: OK (export %s, engine %s, %s)\n",
+			report.Config.ExportVersion, report.Config.EngineVersion, report.Config.HashType)
+	}
+
+	// Storage files
+	for _, file := range report.Files {
+		switch {
+		case file.Err != nil:
+			fmt.Fprintf(a.stdout, "%s: FAILED: %v\n", file.Filename, file.Err)
+		case file.TotalProblems > 0:
+			fmt.Fprintf(a.stdout, "%s: %d records, %d problems\n", file.Filename, file.Records, file.TotalProblems)
+		default:
+			fmt.Fprintf(a.stdout, "%s: OK (%d records)\n", file.Filename, file.Records)
+		}
+
+		for _, problem := range file.Problems {
+			fmt.Fprintf(a.stdout, "  - %v\n", problem)
+		}
+		if hidden := file.TotalProblems - len(file.Problems); hidden > 0 {
+			fmt.Fprintf(a.stdout, "  - ... and %d more\n", hidden)
+		}
+	}
+
+	// Cross-format comparison
+	if len(report.Mismatches) > 0 {
+		fmt.Fprintln(a.stdout, "Storage formats do not match:")
+		for _, mismatch := range report.Mismatches {
+			fmt.Fprintf(a.stdout, "  - %v\n", mismatch)
+		}
+	}
+
+	// Bloom filters
+	for _, filter := range report.BloomFilters {
+		switch {
+		case filter.Err != nil:
+			fmt.Fprintf(a.stdout, "%s: FAILED: %v\n", filter.Filename, filter.Err)
+		case len(filter.Missing) > 0:
+			fmt.Fprintf(a.stdout, "%s: out of date, regenerate it with 'rotten bloom'\n", filter.Filename)
+		default:
+			fmt.Fprintf(a.stdout, "%s: OK (%d hashes, %.2f%% false positives)\n",
+				filter.Filename, filter.Hashes, filter.FalsePositiveRate*100)
+		}
+
+		for _, missing := range filter.Missing {
+			fmt.Fprintf(a.stdout, "  - %v\n", missing)
+		}
+	}
+
+	if !report.OK() {
+		fmt.Fprintln(a.stdout, "Verification FAILED")
+		return ExitFlagged
+	}
+
+	fmt.Fprintln(a.stdout, "Verification passed")
+	return ExitClean
+}
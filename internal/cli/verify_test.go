@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_Verify(t *testing.T) {
+	dir := setupExport(t,
+		[]testRecord{{id: 1, status: "confirmed", reason: "reason", confidence: 0.95}},
+		[]testRecord{{id: 2, status: "flagged", reason: "reason", confidence: 0.5}},
+	)
+
+	code, stdout, _ := run(nil, "verify", dir)
+	assert.Equal(t, ExitClean, code)
+	assert.Contains(t, stdout, "Config: OK")
+	assert.Contains(t, stdout, "users.csv: OK (1 records)")
+	assert.Contains(t, stdout, "Verification passed")
+
+	// Corrupt record is reported with its line number
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "groups.csv"),
+		[]byte("hash,status,reason,confidence\n"+strings.Repeat("ab", 32)+",flagged,reason,2\n"), 0o600))
+
+	code, stdout, _ = run(nil, "verify", dir)
+	assert.Equal(t, ExitFlagged, code)
+	assert.Contains(t, stdout, "groups.csv: 0 records, 1 problems")
+	assert.Contains(t, stdout, "line 2: confidence must be between 0 and 1")
+	assert.Contains(t, stdout, "Verification FAILED")
+
+	code, _, _ = run(nil, "verify")
+	assert.Equal(t, ExitError, code)
+}
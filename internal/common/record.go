@@ -0,0 +1,40 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrInvalidRecordHash = errors.New("hash is not valid hex")
+	ErrInvalidConfidence = errors.New("confidence must be between 0 and 1")
+
+	// ErrStopWalk is returned by a RecordFunc, possibly wrapped, to stop walking a storage file.
+	// The walk then returns it instead of reporting it as a problem with the record.
+	ErrStopWalk = errors.New("walk stopped")
+)
+
+// RecordFunc is called with the hash and contents of each record while walking a storage file.
+type RecordFunc func(hash string, result *CheckResult) error
+
+// ValidateRecord checks that a record has a hex-encoded hash and a confidence between 0 and 1.
+// Hashes must be lowercase, as produced by the hasher, since every storage type and the bloom
+// filters compare them byte for byte.
+func ValidateRecord(hash string, confidence float64) error {
+	if hash == "" {
+		return fmt.Errorf("%w: empty hash", ErrInvalidRecordHash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidRecordHash, hash)
+	}
+	if hash != strings.ToLower(hash) {
+		return fmt.Errorf("%w: %s is not lowercase", ErrInvalidRecordHash, hash)
+	}
+	if math.IsNaN(confidence) || confidence < 0 || confidence > 1 {
+		return fmt.Errorf("%w: %v", ErrInvalidConfidence, confidence)
+	}
+	return nil
+}
@@ -1,5 +1,10 @@
 package common
 
+import "errors"
+
+// ErrCheckerClosed is returned by lookups on a checker that has been closed.
+var ErrCheckerClosed = errors.New("checker is closed")
+
 // CheckType represents the type of check to perform.
 type CheckType string
 
@@ -16,12 +21,42 @@ const (
 	StorageTypeSQLite StorageType = "sqlite"
 	StorageTypeBinary StorageType = "binary"
 	StorageTypeCSV    StorageType = "csv"
+	StorageTypeJSONL  StorageType = "jsonl"
+	StorageTypeCDB    StorageType = "cdb"
+
+	// Indexed storage types load the CSV or binary file into memory once for fast lookups.
+	StorageTypeCSVIndexed    StorageType = "csv-indexed"
+	StorageTypeBinaryIndexed StorageType = "binary-indexed"
+
+	// StorageTypeRemote looks hashes up through the HTTP API of a remote server instead of local files.
+	StorageTypeRemote StorageType = "remote"
 )
 
+// FileType returns the storage type whose files are read for this storage type.
+func (s StorageType) FileType() StorageType {
+	switch s {
+	case StorageTypeCSVIndexed:
+		return StorageTypeCSV
+	case StorageTypeBinaryIndexed:
+		return StorageTypeBinary
+	default:
+		return s
+	}
+}
+
 // CheckResult contains the result of a check operation.
 type CheckResult struct {
-	Found      bool
-	Status     string
-	Reason     string
-	Confidence float64
+	Found      bool    `json:"found"`
+	Status     string  `json:"status,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// FriendResult represents the result of a friend check.
+type FriendResult struct {
+	ID         uint64  `json:"id"`
+	Found      bool    `json:"found"`
+	Status     string  `json:"status,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
 }
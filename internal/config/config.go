@@ -9,7 +9,8 @@ import (
 )
 
 const (
-	configFileName = "export_config.json"
+	// Filename is the name of the configuration file in an export directory.
+	Filename = "export_config.json"
 )
 
 var (
@@ -53,7 +54,7 @@ func LoadOrCreate(dir string) (*Config, error) {
 
 // Load reads the configuration from the specified directory.
 func Load(dir string) (*Config, error) {
-	configPath := filepath.Join(dir, configFileName)
+	configPath := filepath.Join(dir, Filename)
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -74,7 +75,7 @@ func (c *Config) Save(dir string) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	configPath := filepath.Join(dir, configFileName)
+	configPath := filepath.Join(dir, Filename)
 
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -145,7 +145,7 @@ func TestConfig_Save(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify file exists
-		_, err = os.Stat(filepath.Join(tempDir, configFileName))
+		_, err = os.Stat(filepath.Join(tempDir, Filename))
 		assert.NoError(t, err)
 
 		// Load and verify contents
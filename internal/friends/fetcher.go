@@ -0,0 +1,57 @@
+package friends
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jaxron/roapi.go/pkg/api"
+	"github.com/jaxron/roapi.go/pkg/api/resources/friends"
+)
+
+// pageSize is the number of friends requested per API call.
+const pageSize = 50
+
+// Fetcher retrieves friend lists from the Roblox API.
+type Fetcher struct {
+	roAPI *api.API
+}
+
+// NewFetcher creates a new Fetcher instance.
+func NewFetcher(roAPI *api.API) *Fetcher {
+	return &Fetcher{roAPI: roAPI}
+}
+
+// FetchIDs returns the IDs of all friends of the given user.
+func (f *Fetcher) FetchIDs(ctx context.Context, userID uint64) ([]uint64, error) {
+	var (
+		cursor      string
+		hasNextPage = true
+		ids         = make([]uint64, 0)
+	)
+
+	for hasNextPage {
+		// Fetch page of friends
+		params := friends.NewFindFriendsBuilder(userID).
+			WithLimit(pageSize).
+			WithCursor(cursor).
+			Build()
+
+		friendsList, err := f.roAPI.Friends().FindFriends(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch friends: %w", err)
+		}
+
+		for _, friend := range friendsList.PageItems {
+			ids = append(ids, friend.ID)
+		}
+
+		// Check if there are more pages
+		if friendsList.NextCursor != nil {
+			cursor = *friendsList.NextCursor
+		} else {
+			hasNextPage = false
+		}
+	}
+
+	return ids, nil
+}
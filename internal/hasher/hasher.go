@@ -1,4 +1,4 @@
-package tui
+package hasher
 
 import (
 	"crypto/sha256"
@@ -18,14 +18,17 @@ const (
 	HashTypeSHA256 HashType = "sha256"
 )
 
+// Size is the length in bytes of the hashes produced by HashID.
+const Size = 32
+
 // HashResult represents a hashed ID with its index.
 type HashResult struct {
 	Index int
 	Hash  string
 }
 
-// hashID converts a single ID to a hash using the specified algorithm with the provided salt.
-func hashID(id uint64, salt string, hashType HashType, iterations uint32, memory uint32) string {
+// HashID converts a single ID to a hash using the specified algorithm with the provided salt.
+func HashID(id uint64, salt string, hashType HashType, iterations uint32, memory uint32) string {
 	// Convert ID to bytes in little-endian format
 	idBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(idBytes, id)
@@ -34,7 +37,7 @@ func hashID(id uint64, salt string, hashType HashType, iterations uint32, memory
 	switch hashType {
 	case HashTypeArgon2id:
 		// Use Argon2id with specified parameters
-		hash = argon2.IDKey(idBytes, []byte(salt), iterations, memory*1024, 1, 32)
+		hash = argon2.IDKey(idBytes, []byte(salt), iterations, memory*1024, 1, Size)
 	case HashTypeSHA256:
 		// Iterative SHA256 hashing with salt
 		hash = []byte(salt)
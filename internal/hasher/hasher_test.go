@@ -1,4 +1,4 @@
-package tui
+package hasher
 
 import (
 	"encoding/hex"
@@ -75,11 +75,11 @@ func TestHashID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := hashID(tt.id, tt.salt, tt.hashType, tt.iterations, tt.memory)
+			got := HashID(tt.id, tt.salt, tt.hashType, tt.iterations, tt.memory)
 
 			_, err := hex.DecodeString(got)
-			assert.NoError(t, err, "hashID() should produce valid hex string")
-			assert.Equal(t, tt.want, got, "hashID() produced incorrect hash")
+			assert.NoError(t, err, "HashID() should produce valid hex string")
+			assert.Equal(t, tt.want, got, "HashID() produced incorrect hash")
 		})
 	}
 }
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+)
+
+// Result is the structured result of checking a single ID.
+type Result struct {
+	ID            uint64             `json:"id"`
+	CheckType     common.CheckType   `json:"checkType"`
+	ExportVersion string             `json:"exportVersion"`
+	EngineVersion string             `json:"engineVersion"`
+	StorageType   common.StorageType `json:"storageType"`
+
+	// Set for user and group checks
+	*common.CheckResult
+
+	// Set for friends checks
+	Friends *FriendsSummary `json:"friends,omitempty"`
+}
+
+// FriendsSummary contains the flagged friends found by a friends check.
+type FriendsSummary struct {
+	Total   int                   `json:"total"`
+	Flagged int                   `json:"flagged"`
+	Checked int                   `json:"checked"` // Less than Total if the check was stopped early
+	Results []common.FriendResult `json:"results"`
+}
+
+// Complete reports whether every friend was checked.
+func (s *FriendsSummary) Complete() bool {
+	return s.Checked >= s.Total
+}
+
+// NewResult creates a result for a user or group check.
+func NewResult(
+	id uint64, checkType common.CheckType, cfg *config.Config, storageType common.StorageType, result *common.CheckResult,
+) *Result {
+	return &Result{
+		ID:            id,
+		CheckType:     checkType,
+		ExportVersion: cfg.ExportVersion,
+		EngineVersion: cfg.EngineVersion,
+		StorageType:   storageType,
+		CheckResult:   result,
+	}
+}
+
+// NewFriendsResult creates a result for a friends check that checked the given number of friends.
+func NewFriendsResult(
+	id uint64, cfg *config.Config, storageType common.StorageType, flagged []common.FriendResult, checked, total int,
+) *Result {
+	return &Result{
+		ID:            id,
+		CheckType:     common.CheckTypeFriends,
+		ExportVersion: cfg.ExportVersion,
+		EngineVersion: cfg.EngineVersion,
+		StorageType:   storageType,
+		Friends: &FriendsSummary{
+			Total:   total,
+			Flagged: len(flagged),
+			Checked: checked,
+			Results: flagged,
+		},
+	}
+}
+
+// Flagged reports whether the checked ID, or any of its friends, was found in the export.
+func (r *Result) Flagged() bool {
+	if r.Friends != nil {
+		return r.Friends.Flagged > 0
+	}
+	return r.CheckResult != nil && r.Found
+}
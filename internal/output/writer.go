@@ -0,0 +1,200 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+// Format represents the format results are written in.
+type Format string
+
+const (
+	// FormatTable writes human-readable text.
+	FormatTable Format = "table"
+	// FormatJSON writes a single JSON document.
+	FormatJSON Format = "json"
+	// FormatNDJSON writes one JSON object per line.
+	FormatNDJSON Format = "ndjson"
+	// FormatCSV writes one CSV row per checked ID.
+	FormatCSV Format = "csv"
+)
+
+// ParseFormat converts a string to a Format.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(s)); format {
+	case FormatTable, FormatJSON, FormatNDJSON, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, s)
+	}
+}
+
+// Writer writes check results in the selected format.
+//
+// NDJSON and CSV results are written as they arrive. JSON results are buffered
+// until Flush, and table results are aligned in columns at Flush when writing a list.
+type Writer struct {
+	w       io.Writer
+	format  Format
+	list    bool
+	results []*Result
+	csv     *csv.Writer
+}
+
+// NewWriter creates a Writer for the result of a single check.
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{w: w, format: format}
+}
+
+// NewListWriter creates a Writer for the results of many checks.
+// JSON output is written as an array and table output as aligned columns.
+func NewListWriter(w io.Writer, format Format) *Writer {
+	return &Writer{w: w, format: format, list: true}
+}
+
+// Write writes or buffers a single result.
+func (w *Writer) Write(r *Result) error {
+	switch w.format {
+	case FormatNDJSON:
+		if err := json.NewEncoder(w.w).Encode(r); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+		return nil
+	case FormatCSV:
+		return w.writeCSV(r)
+	case FormatTable:
+		if !w.list {
+			writeText(w.w, r)
+			return nil
+		}
+	case FormatJSON:
+	}
+
+	w.results = append(w.results, r)
+	return nil
+}
+
+// Flush writes any buffered results.
+func (w *Writer) Flush() error {
+	switch w.format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w.w)
+		encoder.SetIndent("", "  ")
+
+		var v any = w.results
+		if !w.list {
+			if len(w.results) == 0 {
+				return nil
+			}
+			v = w.results[0]
+		}
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+	case FormatTable:
+		if w.list {
+			return writeTable(w.w, w.results)
+		}
+	case FormatCSV:
+		if w.csv != nil {
+			w.csv.Flush()
+			if err := w.csv.Error(); err != nil {
+				return fmt.Errorf("failed to write results: %w", err)
+			}
+		}
+	case FormatNDJSON:
+	}
+
+	w.results = nil
+	return nil
+}
+
+// writeCSV writes a result as a CSV row, writing the header first if needed.
+func (w *Writer) writeCSV(r *Result) error {
+	if w.csv == nil {
+		w.csv = csv.NewWriter(w.w)
+		if err := w.csv.Write([]string{"id", "found", "status", "reason", "confidence"}); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+	}
+
+	if err := w.csv.Write(resultColumns(r)); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// resultColumns returns the ID, found, status, reason and confidence of a result.
+func resultColumns(r *Result) []string {
+	columns := []string{strconv.FormatUint(r.ID, 10), strconv.FormatBool(r.Flagged()), "", "", ""}
+	switch {
+	case r.Friends != nil:
+		columns[2] = fmt.Sprintf("%d/%d friends flagged", r.Friends.Flagged, r.Friends.Total)
+		if !r.Friends.Complete() {
+			columns[2] += fmt.Sprintf(" (%d checked)", r.Friends.Checked)
+		}
+	case r.Flagged():
+		columns[2] = r.Status
+		columns[3] = r.Reason
+		columns[4] = strconv.FormatFloat(r.Confidence, 'f', 2, 64)
+	}
+	return columns
+}
+
+// writeTable writes results as aligned columns.
+func writeTable(w io.Writer, results []*Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFOUND\tSTATUS\tCONFIDENCE\tREASON")
+	for _, r := range results {
+		columns := resultColumns(r)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", columns[0], columns[1], columns[2], columns[4], columns[3])
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// writeText writes a single result in a human-readable layout.
+func writeText(w io.Writer, r *Result) {
+	if r.Friends != nil {
+		for _, friend := range r.Friends.Results {
+			fmt.Fprintf(w, "Friend %d was FOUND in the export\n", friend.ID)
+			writeDetails(w, friend.Status, friend.Reason, friend.Confidence, "  ")
+		}
+		fmt.Fprintf(w, "%d flagged friends found out of %d total friends\n", r.Friends.Flagged, r.Friends.Total)
+		if !r.Friends.Complete() {
+			fmt.Fprintf(w, "Check stopped after %d of %d friends\n", r.Friends.Checked, r.Friends.Total)
+		}
+		return
+	}
+
+	checkTypeStr := string(r.CheckType)
+	checkTypeStr = strings.ToUpper(checkTypeStr[:1]) + checkTypeStr[1:]
+
+	if !r.Flagged() {
+		fmt.Fprintf(w, "%s ID %d was NOT FOUND in the export\n", checkTypeStr, r.ID)
+		return
+	}
+
+	fmt.Fprintf(w, "%s ID %d was FOUND in the export\n", checkTypeStr, r.ID)
+	writeDetails(w, r.Status, r.Reason, r.Confidence, "")
+}
+
+// writeDetails writes the status, confidence and reasons of a flagged result.
+func writeDetails(w io.Writer, status, reason string, confidence float64, indent string) {
+	fmt.Fprintf(w, "%sStatus: %s\n", indent, status)
+	fmt.Fprintf(w, "%sConfidence: %.2f\n", indent, confidence)
+	fmt.Fprintf(w, "%sReason:\n", indent)
+	for _, part := range strings.Split(reason, "; ") {
+		fmt.Fprintf(w, "%s  - %s\n", indent, part)
+	}
+}
@@ -0,0 +1,159 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResults() []*Result {
+	cfg := &config.Config{EngineVersion: "1.2.3", ExportVersion: "2025-01-01"}
+	return []*Result{
+		NewResult(1, common.CheckTypeUser, cfg, common.StorageTypeSQLite, &common.CheckResult{
+			Found:      true,
+			Status:     "confirmed",
+			Reason:     "first; second",
+			Confidence: 0.95,
+		}),
+		NewResult(2, common.CheckTypeUser, cfg, common.StorageTypeSQLite, &common.CheckResult{}),
+		NewFriendsResult(3, cfg, common.StorageTypeSQLite, []common.FriendResult{
+			{ID: 4, Found: true, Status: "flagged", Reason: "reason", Confidence: 0.5},
+		}, 10, 10),
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"table", "JSON", "ndjson", "csv"} {
+		_, err := ParseFormat(s)
+		assert.NoError(t, err)
+	}
+
+	_, err := ParseFormat("xml")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestResult_Flagged(t *testing.T) {
+	results := testResults()
+	assert.True(t, results[0].Flagged())
+	assert.False(t, results[1].Flagged())
+	assert.True(t, results[2].Flagged())
+}
+
+func TestWriter_JSON(t *testing.T) {
+	results := testResults()
+
+	// Single result is written as an object
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, FormatJSON)
+	require.NoError(t, writer.Write(results[0]))
+	require.NoError(t, writer.Flush())
+
+	var single map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &single))
+	assert.Equal(t, map[string]any{
+		"id":            float64(1),
+		"checkType":     "user",
+		"exportVersion": "2025-01-01",
+		"engineVersion": "1.2.3",
+		"storageType":   "sqlite",
+		"found":         true,
+		"status":        "confirmed",
+		"reason":        "first; second",
+		"confidence":    0.95,
+	}, single)
+
+	// List is written as an array
+	buf.Reset()
+	writer = NewListWriter(&buf, FormatJSON)
+	for _, r := range results {
+		require.NoError(t, writer.Write(r))
+	}
+	require.NoError(t, writer.Flush())
+
+	var list []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &list))
+	require.Len(t, list, 3)
+	assert.Equal(t, false, list[1]["found"])
+	assert.NotContains(t, list[1], "status")
+	assert.Equal(t, float64(1), list[2]["friends"].(map[string]any)["flagged"])
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewListWriter(&buf, FormatNDJSON)
+	for _, r := range testResults() {
+		require.NoError(t, writer.Write(r))
+	}
+	require.NoError(t, writer.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		var v map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &v))
+	}
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewListWriter(&buf, FormatCSV)
+	for _, r := range testResults() {
+		require.NoError(t, writer.Write(r))
+	}
+	require.NoError(t, writer.Flush())
+
+	assert.Equal(t, "id,found,status,reason,confidence\n"+
+		"1,true,confirmed,first; second,0.95\n"+
+		"2,false,,,\n"+
+		"3,true,1/10 friends flagged,,\n", buf.String())
+}
+
+func TestWriter_Table(t *testing.T) {
+	results := testResults()
+
+	// Single result uses the detailed layout
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, FormatTable)
+	require.NoError(t, writer.Write(results[0]))
+	require.NoError(t, writer.Flush())
+	assert.Equal(t, "User ID 1 was FOUND in the export\n"+
+		"Status: confirmed\n"+
+		"Confidence: 0.95\n"+
+		"Reason:\n"+
+		"  - first\n"+
+		"  - second\n", buf.String())
+
+	buf.Reset()
+	writer = NewWriter(&buf, FormatTable)
+	require.NoError(t, writer.Write(results[2]))
+	assert.Contains(t, buf.String(), "Friend 4 was FOUND in the export")
+	assert.Contains(t, buf.String(), "1 flagged friends found out of 10 total friends")
+	assert.NotContains(t, buf.String(), "stopped")
+
+	// Incomplete friends checks show how many friends were checked
+	results[2].Friends.Checked = 4
+	buf.Reset()
+	writer = NewWriter(&buf, FormatTable)
+	require.NoError(t, writer.Write(results[2]))
+	assert.Contains(t, buf.String(), "Check stopped after 4 of 10 friends")
+	results[2].Friends.Checked = 10
+
+	// List uses aligned columns
+	buf.Reset()
+	writer = NewListWriter(&buf, FormatTable)
+	for _, r := range results {
+		require.NoError(t, writer.Write(r))
+	}
+	require.NoError(t, writer.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "ID  FOUND"))
+	assert.Contains(t, lines[1], "confirmed")
+}
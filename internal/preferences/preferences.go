@@ -0,0 +1,83 @@
+package preferences
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/robalyx/rotten/internal/common"
+)
+
+const (
+	dirName  = "rotten"
+	fileName = "preferences.json"
+)
+
+// Preferences stores the selections from the last session.
+type Preferences struct {
+	CheckType   common.CheckType   `json:"checkType,omitempty"`   // Last selected check type
+	ExportDir   string             `json:"exportDir,omitempty"`   // Last used export directory
+	StorageType common.StorageType `json:"storageType,omitempty"` // Last selected storage type
+	Options     *Options           `json:"options,omitempty"`     // Last used UI options, nil if never saved
+}
+
+// Options stores the UI options from the last session.
+type Options struct {
+	Strict bool `json:"strict"` // Refuse exports that would make every lookup slow
+}
+
+// DefaultPath returns the path of the preferences file in the user config directory.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user config directory: %w", err)
+	}
+	return filepath.Join(configDir, dirName, fileName), nil
+}
+
+// Load reads the preferences from path.
+// A missing file results in empty preferences.
+func Load(path string) (*Preferences, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Preferences{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+
+	var prefs Preferences
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, fmt.Errorf("failed to parse preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+// Save writes the preferences to path, creating its directory if needed.
+func (p *Preferences) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create preferences directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes the preferences file at path.
+func Reset(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove preferences: %w", err)
+	}
+	return nil
+}
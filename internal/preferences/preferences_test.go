@@ -0,0 +1,60 @@
+package preferences
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPath(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("AppData", configDir)
+	t.Setenv("HOME", configDir)
+
+	path, err := DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, fileName, filepath.Base(path))
+	assert.Equal(t, dirName, filepath.Base(filepath.Dir(path)))
+}
+
+func TestLoadSaveReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), dirName, fileName)
+
+	// Missing file results in empty preferences
+	prefs, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, &Preferences{}, prefs)
+
+	// Save creates the directory and file
+	prefs = &Preferences{
+		CheckType:   common.CheckTypeGroup,
+		ExportDir:   "exports/official",
+		StorageType: common.StorageTypeBinary,
+		Options:     &Options{Strict: true},
+	}
+	require.NoError(t, prefs.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, prefs, loaded)
+
+	// Reset removes the file and is safe to repeat
+	require.NoError(t, Reset(path))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, Reset(path))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName)
+	require.NoError(t, os.WriteFile(path, []byte("{invalid"), 0o600))
+
+	prefs, err := Load(path)
+	assert.Error(t, err)
+	assert.Nil(t, prefs)
+}
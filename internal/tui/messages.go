@@ -1,6 +1,10 @@
 package tui
 
-import "github.com/robalyx/rotten/internal/exports"
+import (
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/exports"
+)
 
 // ExportsLoadedMsg is sent when exports are loaded from GitHub.
 type ExportsLoadedMsg struct {
@@ -25,11 +29,19 @@ type CheckProgressMsg struct {
 }
 
 // FriendsCheckProgressMsg is sent to indicate progress in checking friends.
+// Stopped is set if the check was cancelled, in which case the results checked so far are included.
 type FriendsCheckProgressMsg struct {
 	Complete      bool
 	Error         error
+	Stopped       bool
 	TotalChecked  int
 	TotalFriends  int
 	FlaggedCount  int
-	FriendResults []FriendResult
+	FriendResults []common.FriendResult
+}
+
+// StatsLoadedMsg is sent when the stats of the export have been collected.
+type StatsLoadedMsg struct {
+	Stats []*checker.Stats
+	Error error
 }
@@ -1,12 +1,18 @@
 package tui
 
 import (
+	"context"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/jaxron/roapi.go/pkg/api"
 	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/bloom"
+	"github.com/robalyx/rotten/internal/checker/indexed"
 	"github.com/robalyx/rotten/internal/common"
 	"github.com/robalyx/rotten/internal/config"
 	"github.com/robalyx/rotten/internal/exports"
+	"github.com/robalyx/rotten/internal/friends"
+	"github.com/robalyx/rotten/internal/preferences"
 )
 
 const OfficialExportDir = "Old Downloaded Export"
@@ -14,16 +20,19 @@ const OfficialExportDir = "Old Downloaded Export"
 // Model handles the state and behavior of the TUI.
 type Model struct {
 	// API clients
-	roAPI      *api.API
+	friends    *friends.Fetcher
 	downloader *exports.Downloader
 
 	// Configuration
-	config *config.Config
+	config    *config.Config
+	exportDir string
+	prefsPath string
 
 	// Core state
-	state     State
-	err       error
-	checkType common.CheckType
+	state        State
+	err          error
+	selectionErr error
+	checkType    common.CheckType
 
 	// Directory selection
 	directories []string
@@ -32,15 +41,23 @@ type Model struct {
 	// Storage configuration
 	storageType         common.StorageType
 	storageTypeSelected int
+	strict              bool // Refuse exports that would make every lookup slow
 
 	// Check type selection
 	checkTypeSelected int
 
 	// ID input and validation
-	id        string
-	validator *checker.Validator
-	checker   checker.Checker
-	hashCount uint64
+	id          string
+	validator   *checker.Validator
+	checker     checker.Checker
+	hashCount   uint64
+	indexStats  *indexed.LoadStats // Set for indexed storage types
+	bloomFilter *bloom.Filter      // Set if the export has a bloom filter for the check type
+	warnings    []error            // Problems of the export that slow down lookups
+	detection   *checker.Detection // Set if the storage type was detected automatically
+
+	// Cancels the check in progress
+	cancel context.CancelFunc
 
 	// Check results
 	result     bool
@@ -49,11 +66,15 @@ type Model struct {
 	confidence float64
 
 	// Friends check specific
-	friendResults      []FriendResult
+	friendResults      []common.FriendResult
 	friendsScrollPos   int
 	flaggedFriendCount int
+	checkedFriendCount int
 	totalFriendCount   int
 
+	// Export stats specific
+	stats []*checker.Stats
+
 	// Export download specific
 	availableExports []*exports.Release
 	selectedExport   int
@@ -62,15 +83,6 @@ type Model struct {
 	checking         bool
 }
 
-// FriendResult represents the result of a friend check.
-type FriendResult struct {
-	ID         uint64
-	Found      bool
-	Status     string
-	Reason     string
-	Confidence float64
-}
-
 // NewModel creates a new Model instance.
 func NewModel() *Model {
 	validator := checker.NewValidator()
@@ -83,15 +95,21 @@ func NewModel() *Model {
 		dirs = append(dirs, currentDirs...)
 	}
 
-	return &Model{
-		roAPI:       api.New(nil),
+	// Preferences are optional, so they are disabled if there is no config directory
+	prefsPath, _ := preferences.DefaultPath()
+
+	m := Model{
+		friends:     friends.NewFetcher(api.New(nil)),
 		state:       StateCheckType,
 		validator:   validator,
 		directories: dirs,
 		err:         err,
 		downloader:  exports.New("robalyx", "rotten"),
 		downloading: false,
+		prefsPath:   prefsPath,
 	}
+	m = m.applyPreferences()
+	return &m
 }
 
 // Init initializes the model.
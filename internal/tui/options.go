@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/common"
+)
+
+// Environment variables that provide defaults for Options.
+const (
+	EnvCheckType   = "ROTTEN_CHECK_TYPE"
+	EnvExportDir   = "ROTTEN_EXPORT_DIR"
+	EnvStorageType = "ROTTEN_STORAGE"
+	EnvStrict      = "ROTTEN_STRICT"
+)
+
+var (
+	ErrInvalidCheckType   = errors.New("invalid check type")
+	ErrInvalidStorageType = errors.New("invalid storage type")
+	ErrExportDirNotFound  = errors.New("export directory not found")
+)
+
+// storageTypeAuto is the storage type menu entry that detects the fastest usable storage type of the export.
+const storageTypeAuto common.StorageType = "auto"
+
+// checkTypeOptions and storageTypeOptions list the menu entries in display order,
+// and storageTypeLabels holds the menu text of each storage type.
+//
+//nolint:gochecknoglobals
+var (
+	checkTypeOptions   = []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup, common.CheckTypeFriends}
+	storageTypeOptions = []common.StorageType{
+		storageTypeAuto, common.StorageTypeSQLite, common.StorageTypeBinary, common.StorageTypeCSV, common.StorageTypeJSONL, common.StorageTypeCDB,
+		common.StorageTypeBinaryIndexed, common.StorageTypeCSVIndexed,
+	}
+	storageTypeLabels = map[common.StorageType]string{
+		storageTypeAuto:                 "Auto (Fastest Available)",
+		common.StorageTypeSQLite:        "SQLite",
+		common.StorageTypeBinary:        "Binary",
+		common.StorageTypeCSV:           "CSV",
+		common.StorageTypeJSONL:         "JSON Lines",
+		common.StorageTypeCDB:           "CDB (Constant Database)",
+		common.StorageTypeBinaryIndexed: "Binary (Indexed)",
+		common.StorageTypeCSVIndexed:    "CSV (Indexed)",
+	}
+)
+
+// Options preselects menu values when starting the TUI.
+// Empty values leave the corresponding menu for the user.
+type Options struct {
+	CheckType   string
+	ExportDir   string
+	StorageType string
+	Strict      bool // Refuse exports that would make every lookup slow
+}
+
+// ParseOptions parses command line flags, using environment variables as defaults.
+// UI options set by neither fall back to the ones saved in the preferences.
+func ParseOptions(args []string, getenv func(string) string, output io.Writer) (Options, error) {
+	var opts Options
+
+	fs := flag.NewFlagSet("rotten", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.CheckType, "check-type", getenv(EnvCheckType),
+		"check type to start with (user, group, friends) [$"+EnvCheckType+"]")
+	fs.StringVar(&opts.ExportDir, "export-dir", getenv(EnvExportDir),
+		"export directory to use [$"+EnvExportDir+"]")
+	fs.StringVar(&opts.StorageType, "storage", getenv(EnvStorageType),
+		"storage type to use (auto, sqlite, binary, csv, jsonl, cdb, csv-indexed, binary-indexed) [$"+EnvStorageType+"]")
+	var strict bool
+	if saved := savedOptions(); saved != nil {
+		strict = saved.Strict
+	}
+	if value, err := strconv.ParseBool(getenv(EnvStrict)); err == nil {
+		strict = value
+	}
+	fs.BoolVar(&opts.Strict, "strict", strict,
+		"refuse exports that would make every lookup slow, such as unindexed SQLite databases [$"+EnvStrict+"]")
+
+	if err := fs.Parse(args); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+// NewModelWithOptions creates a new Model and applies the preselected values in opts.
+// The model starts at the first menu that still needs input. When a value is invalid,
+// the model starts at that value's menu with the error shown.
+func NewModelWithOptions(opts Options) *Model {
+	m := NewModel()
+	*m = m.applyOptions(opts)
+	return m
+}
+
+// applyOptions walks through the menus using the values in opts.
+func (m Model) applyOptions(opts Options) Model {
+	m.strict = opts.Strict
+
+	// Select check type
+	if opts.CheckType == "" {
+		return m
+	}
+	index := slices.Index(checkTypeOptions, common.CheckType(strings.ToLower(opts.CheckType)))
+	if index < 0 {
+		m.selectionErr = fmt.Errorf("%w: %s", ErrInvalidCheckType, opts.CheckType)
+		return m
+	}
+	m.checkTypeSelected = index
+	m.checkType = checkTypeOptions[index]
+	m.state = StateDirectory
+
+	// Select export directory
+	if opts.ExportDir == "" {
+		return m
+	}
+	m, dirIndex, err := m.addDirectory(opts.ExportDir)
+	if err != nil {
+		m.selectionErr = err
+		return m
+	}
+	m.selected = dirIndex
+	m.state = StateStorageType
+
+	// Select storage type
+	if opts.StorageType == "" {
+		return m
+	}
+	index = slices.Index(storageTypeOptions, common.StorageType(strings.ToLower(opts.StorageType)))
+	if index < 0 {
+		m.selectionErr = fmt.Errorf("%w: %s", ErrInvalidStorageType, opts.StorageType)
+		return m
+	}
+	m.storageTypeSelected = index
+	m.storageType = storageTypeOptions[index]
+
+	// Load export and go straight to ID input
+	loaded, err := m.loadExport()
+	if err != nil {
+		m.selectionErr = err
+		if !errors.Is(err, checker.ErrMissingFile) {
+			// Problem with the export itself rather than the chosen storage type
+			m.state = StateDirectory
+			m.selected = dirIndex + 1 // First option is the official export download
+		}
+		return m
+	}
+	loaded.savePreferences()
+	loaded.state = StateIDInput
+	return loaded
+}
+
+// addDirectory adds dir to the directory list if it is not already present and returns its index.
+func (m Model) addDirectory(dir string) (Model, int, error) {
+	dir = filepath.Clean(dir)
+	if dirs, err := m.validator.GetExportDirs(dir); err != nil || !slices.Contains(dirs, dir) {
+		return m, 0, fmt.Errorf("%w: %s", ErrExportDirNotFound, dir)
+	}
+
+	for i, existing := range m.directories {
+		if samePath(existing, dir) {
+			return m, i, nil
+		}
+	}
+
+	m.directories = append(m.directories, dir)
+	return m, len(m.directories) - 1, nil
+}
+
+// samePath reports whether two paths refer to the same location.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/checker"
+	"github.com/robalyx/rotten/internal/checker/sqlite"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zsqlite "zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+func setupExport(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := &config.Config{
+		EngineVersion: "1.0.0",
+		ExportVersion: "1.0.0",
+		Salt:          "test_salt",
+		HashType:      "sha256",
+		Iterations:    1,
+	}
+	require.NoError(t, cfg.Save(dir))
+
+	for _, filename := range []string{"users.csv", "groups.csv"} {
+		err := os.WriteFile(filepath.Join(dir, filename), []byte("hash,status,reason,confidence\n"), 0o600)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func TestParseOptions(t *testing.T) {
+	usePreferencesDir(t)
+	env := map[string]string{
+		EnvCheckType:   "group",
+		EnvExportDir:   "env_dir",
+		EnvStorageType: "binary",
+		EnvStrict:      "true",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	// Environment variables are used as defaults
+	opts, err := ParseOptions(nil, getenv, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, Options{CheckType: "group", ExportDir: "env_dir", StorageType: "binary", Strict: true}, opts)
+
+	// Flags override environment variables
+	opts, err = ParseOptions([]string{"--check-type", "user", "--storage", "csv", "--strict=false"}, getenv, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, Options{CheckType: "user", ExportDir: "env_dir", StorageType: "csv"}, opts)
+
+	_, err = ParseOptions([]string{"--unknown"}, getenv, io.Discard)
+	assert.Error(t, err)
+}
+
+func TestNewModelWithOptions(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		wantState State
+		wantErr   error
+	}{
+		{
+			name:      "No options",
+			opts:      Options{},
+			wantState: StateCheckType,
+		},
+		{
+			name:      "Check type only",
+			opts:      Options{CheckType: "group"},
+			wantState: StateDirectory,
+		},
+		{
+			name:      "Check type and directory",
+			opts:      Options{CheckType: "user", ExportDir: dir},
+			wantState: StateStorageType,
+		},
+		{
+			name:      "All options",
+			opts:      Options{CheckType: "user", ExportDir: dir, StorageType: "csv"},
+			wantState: StateIDInput,
+		},
+		{
+			name:      "Friends with CSV",
+			opts:      Options{CheckType: "friends", ExportDir: dir, StorageType: "csv"},
+			wantState: StateIDInput,
+		},
+		{
+			name:      "Friends with auto",
+			opts:      Options{CheckType: "friends", ExportDir: dir, StorageType: "auto"},
+			wantState: StateIDInput,
+		},
+		{
+			name:      "Invalid check type",
+			opts:      Options{CheckType: "place", ExportDir: dir, StorageType: "csv"},
+			wantState: StateCheckType,
+			wantErr:   ErrInvalidCheckType,
+		},
+		{
+			name:      "Missing directory",
+			opts:      Options{CheckType: "user", ExportDir: filepath.Join(dir, "missing"), StorageType: "csv"},
+			wantState: StateDirectory,
+			wantErr:   ErrExportDirNotFound,
+		},
+		{
+			name:      "Invalid storage type",
+			opts:      Options{CheckType: "user", ExportDir: dir, StorageType: "xml"},
+			wantState: StateStorageType,
+			wantErr:   ErrInvalidStorageType,
+		},
+		{
+			name:      "Storage files missing",
+			opts:      Options{CheckType: "user", ExportDir: dir, StorageType: "sqlite"},
+			wantState: StateStorageType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModelWithOptions(tt.opts)
+			assert.Equal(t, tt.wantState, m.state)
+			assert.NoError(t, m.err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, m.selectionErr, tt.wantErr)
+			}
+		})
+	}
+
+	// Fully preselected model is ready for ID input
+	m := NewModelWithOptions(Options{CheckType: "USER", ExportDir: dir, StorageType: "csv"})
+	assert.Equal(t, common.CheckTypeUser, m.checkType)
+	assert.Equal(t, common.StorageTypeCSV, m.storageType)
+	assert.NotNil(t, m.checker)
+	assert.NotNil(t, m.config)
+	assert.Equal(t, dir, m.directories[m.selected])
+}
+
+func TestNewModelWithOptions_Strict(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	// SQLite export without an index on the hash column
+	conn, err := zsqlite.OpenConn(filepath.Join(dir, "users.db"), zsqlite.OpenCreate|zsqlite.OpenReadWrite)
+	require.NoError(t, err)
+	require.NoError(t, sqlitex.ExecScript(conn, "CREATE TABLE users (hash TEXT, status TEXT, reason TEXT, confidence REAL);"))
+	require.NoError(t, conn.Close())
+
+	// The export opens with the warning shown in the info panel
+	m := NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "sqlite"})
+	assert.Equal(t, StateIDInput, m.state)
+	require.Len(t, m.warnings, 1)
+	assert.Contains(t, m.View(), "Warning: hash column has no index or unique constraint")
+
+	m = NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "sqlite", Strict: true})
+	assert.Equal(t, StateDirectory, m.state)
+	assert.ErrorIs(t, m.selectionErr, sqlite.ErrUnindexedHash)
+}
+
+func TestNewModelWithOptions_Auto(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	// The detected storage type and the reason for choosing it are shown in the info panel
+	m := NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "auto"})
+	assert.Equal(t, StateIDInput, m.state)
+	assert.Equal(t, storageTypeAuto, m.storageType)
+	assert.Equal(t, common.StorageTypeCSVIndexed, m.exportStorageType())
+	assert.Contains(t, m.View(), "Storage: csv-indexed (auto: CSV file is loaded")
+
+	// Files that cannot be used are shown as warnings
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.bin"), []byte("xx"), 0o600))
+	m = NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "auto"})
+	assert.Equal(t, StateIDInput, m.state)
+	require.Len(t, m.warnings, 1)
+	assert.Contains(t, m.View(), "Warning: users.bin")
+
+	// Exports without a usable file are refused
+	require.NoError(t, os.Remove(filepath.Join(dir, "users.csv")))
+	m = NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "auto"})
+	assert.Equal(t, StateDirectory, m.state)
+	assert.ErrorIs(t, m.selectionErr, checker.ErrNoUsableStorage)
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/robalyx/rotten/internal/preferences"
+)
+
+// applyPreferences moves the menu cursors to the selections saved in the last session.
+func (m Model) applyPreferences() Model {
+	if m.prefsPath == "" {
+		return m
+	}
+
+	prefs, err := preferences.Load(m.prefsPath)
+	if err != nil {
+		return m // Unreadable preferences are ignored
+	}
+
+	if index := slices.Index(checkTypeOptions, prefs.CheckType); index >= 0 {
+		m.checkTypeSelected = index
+	}
+	if index := slices.Index(storageTypeOptions, prefs.StorageType); index >= 0 {
+		m.storageTypeSelected = index
+	}
+	if prefs.ExportDir != "" {
+		if updated, index, err := m.addDirectory(prefs.ExportDir); err == nil {
+			m = updated
+			m.selected = index + 1 // First option is the official export download
+		}
+	}
+
+	return m
+}
+
+// savePreferences stores the current selections for the next session.
+// Preferences are a convenience, so failures to save them are ignored.
+func (m Model) savePreferences() {
+	if m.prefsPath == "" {
+		return
+	}
+
+	prefs, err := preferences.Load(m.prefsPath)
+	if err != nil {
+		prefs = &preferences.Preferences{}
+	}
+
+	prefs.CheckType = m.checkType
+	prefs.StorageType = m.storageType
+	prefs.Options = &preferences.Options{Strict: m.strict}
+
+	// Downloaded exports are kept in a temporary directory, so only remember local exports
+	if dir := m.directories[m.selected]; dir != OfficialExportDir {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			prefs.ExportDir = absDir
+		}
+	}
+
+	_ = prefs.Save(m.prefsPath)
+}
+
+// savedOptions returns the UI options saved in the last session, or nil if there are none.
+func savedOptions() *preferences.Options {
+	path, err := preferences.DefaultPath()
+	if err != nil {
+		return nil
+	}
+	prefs, err := preferences.Load(path)
+	if err != nil {
+		return nil // Unreadable preferences are ignored
+	}
+	return prefs.Options
+}
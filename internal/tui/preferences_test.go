@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"io"
+	"testing"
+
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/robalyx/rotten/internal/preferences"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usePreferencesDir points the user config directory at a temporary directory.
+func usePreferencesDir(t *testing.T) string {
+	t.Helper()
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("AppData", configDir)
+	t.Setenv("HOME", configDir)
+
+	path, err := preferences.DefaultPath()
+	require.NoError(t, err)
+	return path
+}
+
+func TestPreferences_SavedAndApplied(t *testing.T) {
+	path := usePreferencesDir(t)
+	dir := setupExport(t)
+
+	// Loading an export saves the selections
+	m := NewModelWithOptions(Options{CheckType: "group", ExportDir: dir, StorageType: "csv"})
+	require.Equal(t, StateIDInput, m.state)
+
+	prefs, err := preferences.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, common.CheckTypeGroup, prefs.CheckType)
+	assert.Equal(t, common.StorageTypeCSV, prefs.StorageType)
+	assert.Equal(t, dir, prefs.ExportDir)
+
+	// New model starts at the first menu with the saved selections highlighted
+	m = NewModel()
+	assert.Equal(t, StateCheckType, m.state)
+	assert.Equal(t, common.CheckTypeGroup, checkTypeOptions[m.checkTypeSelected])
+	assert.Equal(t, common.StorageTypeCSV, storageTypeOptions[m.storageTypeSelected])
+	assert.Equal(t, dir, m.directories[m.selected-1])
+}
+
+func TestPreferences_UIOptions(t *testing.T) {
+	path := usePreferencesDir(t)
+	dir := setupExport(t)
+	noEnv := func(string) string { return "" }
+
+	// Loading an export saves the UI options
+	m := NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "csv", Strict: true})
+	require.Equal(t, StateIDInput, m.state)
+
+	prefs, err := preferences.Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, prefs.Options)
+	assert.True(t, prefs.Options.Strict)
+
+	// Saved options apply when neither a flag nor an environment variable sets them
+	opts, err := ParseOptions(nil, noEnv, io.Discard)
+	require.NoError(t, err)
+	assert.True(t, opts.Strict)
+
+	opts, err = ParseOptions(nil, func(key string) string {
+		if key == EnvStrict {
+			return "false"
+		}
+		return ""
+	}, io.Discard)
+	require.NoError(t, err)
+	assert.False(t, opts.Strict)
+
+	opts, err = ParseOptions([]string{"--strict=false"}, noEnv, io.Discard)
+	require.NoError(t, err)
+	assert.False(t, opts.Strict)
+}
+
+func TestPreferences_MissingExportIgnored(t *testing.T) {
+	path := usePreferencesDir(t)
+
+	prefs := &preferences.Preferences{
+		CheckType:   common.CheckTypeFriends,
+		ExportDir:   t.TempDir(),
+		StorageType: "unknown",
+	}
+	require.NoError(t, prefs.Save(path))
+
+	m := NewModel()
+	assert.Equal(t, common.CheckTypeFriends, checkTypeOptions[m.checkTypeSelected])
+	assert.Equal(t, 0, m.storageTypeSelected)
+	assert.Equal(t, 0, m.selected)
+	assert.NoError(t, m.err)
+}
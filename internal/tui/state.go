@@ -8,7 +8,7 @@ const (
 	StateCheckType State = iota
 	// StateDirectory is the state where user selects an export directory.
 	StateDirectory
-	// StateStorageType is the state where user selects storage type (SQLite/Binary/CSV).
+	// StateStorageType is the state where user selects storage type (SQLite/Binary/CSV/JSON Lines).
 	StateStorageType
 	// StateIDInput is the state where user enters an ID to check.
 	StateIDInput
@@ -18,4 +18,6 @@ const (
 	StateFriendsResult
 	// StateExportDownload is the state where user can download official exports.
 	StateExportDownload
+	// StateStats is the state where a summary of the export contents is displayed.
+	StateStats
 )
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_Stats(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+	content := "hash,status,reason,confidence\n" +
+		"0123456789abcdef,confirmed,bad name; bad avatar,0.9\n" +
+		"fedcba9876543210,flagged,bad name,0.4\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.csv"), []byte(content), 0o600))
+
+	m := *NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "csv"})
+	require.Equal(t, StateIDInput, m.state)
+
+	// Pressing 's' starts collecting stats
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = updated.(Model)
+	assert.Equal(t, StateStats, m.state)
+	assert.Empty(t, m.id)
+	require.NotNil(t, cmd)
+
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	require.Len(t, m.stats, 2)
+	assert.Equal(t, 2, m.stats[0].Total)
+	assert.Zero(t, m.stats[1].Total)
+	assert.Contains(t, m.View(), "bad name")
+
+	// Enter returns to the ID input
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, StateIDInput, updated.(Model).state)
+}
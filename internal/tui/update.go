@@ -2,18 +2,20 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/jaxron/roapi.go/pkg/api/resources/friends"
 	"github.com/robalyx/rotten/internal/checker"
 	"github.com/robalyx/rotten/internal/common"
 	"github.com/robalyx/rotten/internal/config"
 	"github.com/robalyx/rotten/internal/exports"
+	"github.com/robalyx/rotten/internal/hasher"
 )
 
 // Update handles UI events and updates the model state.
@@ -64,7 +66,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case CheckProgressMsg:
 		// Handle completion of ID check
-		m.checking = false
+		m = m.finishCheck()
+		if errors.Is(msg.Error, context.Canceled) {
+			return m, nil // Stay on the ID input
+		}
 		if msg.Error != nil {
 			m.err = msg.Error
 			return m, nil
@@ -78,17 +83,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case FriendsCheckProgressMsg:
-		// Handle completion of friends check
-		m.checking = false
-		if msg.Error != nil {
+		// Handle completion of friends check, which may have been stopped early
+		m = m.finishCheck()
+		if msg.Error != nil && !msg.Stopped {
 			m.err = msg.Error
 			return m, nil
 		}
+		m.checkedFriendCount = msg.TotalChecked
 		m.totalFriendCount = msg.TotalFriends
 		m.flaggedFriendCount = msg.FlaggedCount
 		m.friendResults = msg.FriendResults
 		m.state = StateFriendsResult
 		return m, nil
+
+	case StatsLoadedMsg:
+		// Handle completion of stats collection
+		m.checking = false
+		if msg.Error != nil {
+			m.err = msg.Error
+			return m, nil
+		}
+		m.stats = msg.Stats
+		return m, nil
 	}
 	return m, nil
 }
@@ -98,8 +114,18 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
 		return m, tea.Quit
+	case "esc":
+		// Stop the check in progress
+		if m.checking && m.cancel != nil {
+			m.cancel()
+		}
+		return m, nil
 	case "r":
-		// Reset state
+		// Stop any check in progress and reset state
+		if m.cancel != nil {
+			m.cancel()
+		}
+		m.closeChecker()
 		m = *NewModel()
 		return m, nil
 	case "up", "k":
@@ -108,9 +134,24 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "down", "j":
 		// Handle downward navigation
 		return m.handleDownKey(), nil
+	case "a":
+		// Open the selected export with the storage type detected automatically
+		if m.state == StateDirectory && m.selected > 0 {
+			m.selected--
+			m.selectionErr = nil
+			m.storageTypeSelected = slices.Index(storageTypeOptions, storageTypeAuto)
+			m.storageType = storageTypeAuto
+			return m.handleStorageSelection()
+		}
+	case "s":
+		// Show export stats
+		if m.state == StateIDInput && !m.checking {
+			return m.handleStatsKey()
+		}
 	case "enter":
 		// Reset if there's an error
 		if m.err != nil {
+			m.closeChecker()
 			m = *NewModel()
 			return m, nil
 		}
@@ -154,7 +195,7 @@ func (m Model) handleUpKey() tea.Model {
 func (m Model) handleDownKey() tea.Model {
 	switch m.state {
 	case StateCheckType:
-		if m.checkTypeSelected < 2 {
+		if m.checkTypeSelected < len(checkTypeOptions)-1 {
 			m.checkTypeSelected++
 		}
 	case StateDirectory:
@@ -162,7 +203,7 @@ func (m Model) handleDownKey() tea.Model {
 			m.selected++
 		}
 	case StateStorageType:
-		if m.storageTypeSelected < 2 {
+		if m.storageTypeSelected < len(storageTypeOptions)-1 {
 			m.storageTypeSelected++
 		}
 	case StateExportDownload:
@@ -193,14 +234,8 @@ func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 
 	case StateCheckType:
 		// Set check type based on selection
-		switch m.checkTypeSelected {
-		case 0:
-			m.checkType = common.CheckTypeUser
-		case 1:
-			m.checkType = common.CheckTypeGroup
-		case 2:
-			m.checkType = common.CheckTypeFriends
-		}
+		m.checkType = checkTypeOptions[m.checkTypeSelected]
+		m.selectionErr = nil
 		m.state = StateDirectory
 
 	case StateDirectory:
@@ -210,18 +245,13 @@ func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 			return m, m.loadExportsCmd()
 		}
 		m.selected--
+		m.selectionErr = nil
 		m.state = StateStorageType
 
 	case StateStorageType:
 		// Set storage type based on selection
-		switch m.storageTypeSelected {
-		case 0:
-			m.storageType = common.StorageTypeSQLite
-		case 1:
-			m.storageType = common.StorageTypeBinary
-		case 2:
-			m.storageType = common.StorageTypeCSV
-		}
+		m.storageType = storageTypeOptions[m.storageTypeSelected]
+		m.selectionErr = nil
 		return m.handleStorageSelection()
 
 	case StateIDInput:
@@ -233,6 +263,12 @@ func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 			return m.handleIDSubmission()
 		}
 
+	case StateStats:
+		// Return to ID input once stats are loaded
+		if !m.checking {
+			m.state = StateIDInput
+		}
+
 	case StateUserGroupResult, StateFriendsResult:
 		// Reset for new ID input
 		m.state = StateIDInput
@@ -267,55 +303,111 @@ func (m Model) handleIDInput(msg tea.KeyMsg) tea.Model {
 
 // handleStorageSelection initializes the checker after storage type selection.
 func (m Model) handleStorageSelection() (tea.Model, tea.Cmd) {
+	m, err := m.loadExport()
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.savePreferences()
+	m.state = StateIDInput
+	return m, nil
+}
+
+// loadExport validates the selected export, loads its configuration and initializes the checker.
+func (m Model) loadExport() (Model, error) {
 	// Get actual directory path
 	dir := m.directories[m.selected]
-	tempDir := ""
 
 	// If using downloaded export, get temp directory path
 	if dir == OfficialExportDir {
-		tempDir = filepath.Join(os.TempDir(), "rotector-exports")
-		dir = tempDir
+		dir = filepath.Join(os.TempDir(), "rotector-exports")
+	}
 
+	m, err := m.openExport(dir)
+	if err != nil && m.directories[m.selected] == OfficialExportDir {
 		// Clean up temp directory when done
-		defer func() {
-			if m.err != nil {
-				os.RemoveAll(tempDir)
-			}
-		}()
+		os.RemoveAll(dir)
+	}
+	return m, err
+}
+
+// openExport opens the export in dir using the selected check and storage types.
+func (m Model) openExport(dir string) (Model, error) {
+	// Detect the storage type if the user left it to us
+	storageType := m.storageType
+	m.detection = nil
+	if storageType == storageTypeAuto {
+		detection, err := checker.Detect(dir, m.checkType)
+		if err != nil {
+			return m, fmt.Errorf("invalid export directory: %w", err)
+		}
+		m.detection, storageType = detection, detection.StorageType
 	}
 
 	// Validate export directory
-	if err := m.validator.ValidateExportDir(dir, m.checkType, m.storageType); err != nil {
-		m.err = fmt.Errorf("invalid export directory: %w", err)
-		return m, nil
+	if err := m.validator.ValidateExportDir(dir, m.checkType, storageType); err != nil {
+		return m, fmt.Errorf("invalid export directory: %w", err)
 	}
 
 	// Load configuration
 	cfg, err := config.LoadOrCreate(dir)
 	if err != nil {
-		m.err = fmt.Errorf("failed to load configuration: %w", err)
-		return m, nil
+		return m, fmt.Errorf("failed to load configuration: %w", err)
 	}
 	m.config = cfg
+	m.exportDir = dir
 
-	// Initialize checker
-	m.checker, err = checker.New(dir, m.storageType)
+	// Initialize checker, closing the one of the previous export
+	m.closeChecker()
+	m.checker, err = checker.NewWithOptions(dir, storageType, checker.Options{Strict: m.strict})
 	if err != nil {
-		m.err = err
-		return m, nil
+		return m, err
+	}
+	m.warnings = checker.Warnings(m.checker)
+	if m.detection != nil {
+		m.warnings = append(m.warnings, m.detection.Skipped...)
 	}
 
 	// Get hash count
-	m.hashCount, err = m.checker.GetHashCount(m.checkType)
+	m.hashCount, err = m.checker.GetHashCount(context.Background(), m.checkType)
 	if err != nil {
-		m.err = err
-		return m, nil
+		return m, err
+	}
+
+	// Report the cost of loading indexed exports, which GetHashCount has just loaded
+	m.indexStats = nil
+	if c, ok := m.checker.(checker.IndexedChecker); ok {
+		stats, err := c.LoadStats(context.Background(), m.checkType)
+		if err != nil {
+			return m, err
+		}
+		m.indexStats = &stats
+	}
+
+	m.bloomFilter = nil
+	if c, ok := m.checker.(checker.FilteredChecker); ok {
+		m.bloomFilter = c.BloomFilter(m.checkType)
 	}
 
-	m.state = StateIDInput
 	return m, nil
 }
 
+// exportStorageType returns the storage type of the open export, which is detected when Auto is selected.
+func (m Model) exportStorageType() common.StorageType {
+	if m.detection != nil {
+		return m.detection.StorageType
+	}
+	return m.storageType
+}
+
+// closeChecker closes the checker of the open export, if any.
+func (m Model) closeChecker() {
+	if m.checker != nil {
+		m.checker.Close()
+	}
+}
+
 // handleIDSubmission processes the entered ID and performs the check.
 func (m Model) handleIDSubmission() (tea.Model, tea.Cmd) {
 	if m.checking {
@@ -330,16 +422,20 @@ func (m Model) handleIDSubmission() (tea.Model, tea.Cmd) {
 	}
 
 	m.checking = true
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
 	return m, func() tea.Msg {
 		// Hash ID and check against export
-		hashType := HashType(m.config.HashType)
-		hash := hashID(id, m.config.Salt, hashType, m.config.Iterations, m.config.Memory)
+		hashType := hasher.HashType(m.config.HashType)
+		hash := hasher.HashID(id, m.config.Salt, hashType, m.config.Iterations, m.config.Memory)
 
-		result, err := m.checker.Check(m.checkType, hash)
+		result, err := m.checker.Check(ctx, m.checkType, hash)
+		if err != nil {
+			return CheckProgressMsg{Complete: true, Error: err}
+		}
 		return CheckProgressMsg{
 			Complete:   true,
-			Error:      err,
-			Found:      result != nil && result.Found,
+			Found:      result.Found,
 			Status:     result.Status,
 			Reason:     result.Reason,
 			Confidence: result.Confidence,
@@ -361,76 +457,93 @@ func (m Model) handleFriendsCheck() (tea.Model, tea.Cmd) {
 	}
 
 	m.checking = true
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
 	return m, func() tea.Msg {
-		var (
-			cursor        string
-			hasNextPage   = true
-			totalChecked  = 0
-			flaggedCount  = 0
-			friendResults = make([]FriendResult, 0)
-		)
-
-		hashType := HashType(m.config.HashType)
-		for hasNextPage {
-			// Fetch page of friends
-			params := friends.NewFindFriendsBuilder(userID).
-				WithLimit(50).
-				WithCursor(cursor).
-				Build()
-
-			friendsList, err := m.roAPI.Friends().FindFriends(context.Background(), params)
-			if err != nil {
-				return FriendsCheckProgressMsg{
-					Complete: true,
-					Error:    fmt.Errorf("failed to fetch friends: %w", err),
-				}
+		// Fetch all friends of the user
+		friendIDs, err := m.friends.FetchIDs(ctx, userID)
+		if err != nil {
+			return FriendsCheckProgressMsg{
+				Complete: true,
+				Error:    err,
+				Stopped:  errors.Is(err, context.Canceled),
 			}
+		}
 
-			// Update total count and process current page
-			totalChecked += len(friendsList.PageItems)
-
-			// Check each friend in current page
-			for _, friend := range friendsList.PageItems {
-				hash := hashID(friend.ID, m.config.Salt, hashType, m.config.Iterations, m.config.Memory)
-
-				result, err := m.checker.Check(common.CheckTypeUser, hash)
-				if err != nil {
-					return FriendsCheckProgressMsg{
-						Complete: true,
-						Error:    fmt.Errorf("failed to check friend %d: %w", friend.ID, err),
-					}
-				}
-
-				if result.Found {
-					flaggedCount++
-					friendResults = append(friendResults, FriendResult{
-						ID:         friend.ID,
-						Found:      true,
-						Status:     result.Status,
-						Reason:     result.Reason,
-						Confidence: result.Confidence,
-					})
-				}
+		// Check friends, keeping the results checked so far if stopped
+		hashType := hasher.HashType(m.config.HashType)
+		results, err := checker.CheckIDs(ctx, m.checker, common.CheckTypeUser, friendIDs, func(id uint64) string {
+			return hasher.HashID(id, m.config.Salt, hashType, m.config.Iterations, m.config.Memory)
+		})
+		if results == nil {
+			return FriendsCheckProgressMsg{
+				Complete: true,
+				Error:    fmt.Errorf("failed to check friends: %w", err),
 			}
+		}
 
-			// Check if there are more pages
-			if friendsList.NextCursor != nil {
-				cursor = *friendsList.NextCursor
-			} else {
-				hasNextPage = false
+		flaggedCount := 0
+		friendResults := make([]common.FriendResult, 0)
+		for i, result := range results {
+			if friendID := friendIDs[i]; result.Found {
+				flaggedCount++
+				friendResults = append(friendResults, common.FriendResult{
+					ID:         friendID,
+					Found:      true,
+					Status:     result.Status,
+					Reason:     result.Reason,
+					Confidence: result.Confidence,
+				})
 			}
 		}
 
 		return FriendsCheckProgressMsg{
 			Complete:      true,
-			TotalChecked:  totalChecked,
-			TotalFriends:  totalChecked,
+			Error:         err,
+			Stopped:       err != nil,
+			TotalChecked:  len(results),
+			TotalFriends:  len(friendIDs),
 			FlaggedCount:  flaggedCount,
 			FriendResults: friendResults,
 		}
 	}
 }
 
+// handleStatsKey switches to the stats view and collects the stats of the export.
+func (m Model) handleStatsKey() (tea.Model, tea.Cmd) {
+	m.state = StateStats
+	m.stats = nil
+	m.checking = true
+
+	dir, storageType := m.exportDir, m.exportStorageType()
+	return m, func() tea.Msg {
+		var stats []*checker.Stats
+		for _, checkType := range []common.CheckType{common.CheckTypeUser, common.CheckTypeGroup} {
+			// Exports may contain only one check type
+			if err := m.validator.ValidateExportDir(dir, checkType, storageType); err != nil {
+				continue
+			}
+
+			s, err := checker.CollectStats(dir, checkType, storageType)
+			if err != nil {
+				return StatsLoadedMsg{Error: err}
+			}
+			stats = append(stats, s)
+		}
+		return StatsLoadedMsg{Stats: stats}
+	}
+}
+
+// finishCheck clears the state of the check in progress.
+func (m Model) finishCheck() Model {
+	m.checking = false
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	return m
+}
+
 // loadExportsCmd creates a command to load available exports.
 func (m Model) loadExportsCmd() tea.Cmd {
 	return func() tea.Msg {
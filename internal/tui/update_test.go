@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robalyx/rotten/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_CancelCheck(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	m := *NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "csv"})
+	require.Equal(t, StateIDInput, m.state)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("123")})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	require.True(t, m.checking)
+	require.NotNil(t, cmd)
+
+	// Pressing esc stops the check and returns to the ID input
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, _ = updated.Update(cmd())
+	m = updated.(Model)
+	assert.False(t, m.checking)
+	assert.NoError(t, m.err)
+	assert.Equal(t, StateIDInput, m.state)
+	assert.Nil(t, m.cancel)
+}
+
+func TestModel_SwitchExportClosesChecker(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	m := *NewModelWithOptions(Options{CheckType: "user", ExportDir: dir, StorageType: "csv"})
+	require.Equal(t, StateIDInput, m.state)
+	old := m.checker
+
+	m, err := m.openExport(setupExport(t))
+	require.NoError(t, err)
+	assert.NotSame(t, old, m.checker)
+
+	// The checker of the previous export is closed
+	_, err = old.GetHashCount(context.Background(), common.CheckTypeUser)
+	require.ErrorIs(t, err, common.ErrCheckerClosed)
+
+	_, err = m.checker.GetHashCount(context.Background(), common.CheckTypeUser)
+	assert.NoError(t, err)
+}
+
+func TestModel_AutoStorageKey(t *testing.T) {
+	usePreferencesDir(t)
+	dir := setupExport(t)
+
+	m := *NewModelWithOptions(Options{CheckType: "group", ExportDir: dir})
+	m.state = StateDirectory
+	m.selected++ // First option is the official export download
+
+	// Pressing 'a' skips the storage type menu
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	assert.Equal(t, StateIDInput, m.state)
+	assert.Equal(t, common.StorageTypeCSVIndexed, m.exportStorageType())
+	assert.Equal(t, storageTypeAuto, storageTypeOptions[m.storageTypeSelected])
+
+	// The stats use the detected storage type
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	require.NotNil(t, cmd)
+	updated, _ = updated.Update(cmd())
+	m = updated.(Model)
+	require.NoError(t, m.err)
+	assert.Len(t, m.stats, 2)
+}
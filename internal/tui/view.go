@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/robalyx/rotten/internal/checker"
 	"github.com/robalyx/rotten/internal/exports"
 )
 
@@ -33,6 +34,8 @@ func (m Model) View() string {
 		return m.renderFriendsResultView(header)
 	case StateExportDownload:
 		return m.renderExportDownloadView(header)
+	case StateStats:
+		return m.renderStatsView(header)
 	default:
 		return boxStyle.Render(header + "\n\nUnknown state")
 	}
@@ -54,6 +57,14 @@ func (m Model) renderError(header string) string {
 	return boxStyle.Render(content)
 }
 
+// renderSelectionError renders the error of an invalid preselected value, if any.
+func (m Model) renderSelectionError() string {
+	if m.selectionErr == nil {
+		return ""
+	}
+	return failureStyle.Render(fmt.Sprintf("Error: %v", m.selectionErr)) + "\n\n"
+}
+
 // renderCheckTypeView renders the check type selection menu.
 func (m Model) renderCheckTypeView(header string) string {
 	options := []string{"User", "Group", "Friends"}
@@ -67,8 +78,9 @@ func (m Model) renderCheckTypeView(header string) string {
 		optionsText += "\n"
 	}
 
-	content := fmt.Sprintf("%s\n\n%s\n\n%s%s",
+	content := fmt.Sprintf("%s\n\n%s%s\n\n%s%s",
 		header,
+		m.renderSelectionError(),
 		titleStyle.Render("What would you like to check?"),
 		optionsText,
 		fmt.Sprintf("%s\n%s",
@@ -91,32 +103,34 @@ func (m Model) renderDirectoryView(header string) string {
 		optionsText += "\n"
 	}
 
-	content := fmt.Sprintf("%s\n\n%s\n\n%s\n%s\n%s",
+	content := fmt.Sprintf("%s\n\n%s%s\n\n%s\n%s\n%s\n%s",
 		header,
+		m.renderSelectionError(),
 		titleStyle.Render("Select a directory:"),
 		optionsText,
 		helpStyle.Render("Use arrow keys to select and enter to confirm"),
+		helpStyle.Render("Press 'a' to open the export with the fastest storage type it has"),
 		helpStyle.Render("Press 'r' to start over or ctrl+c to quit"))
 	return boxStyle.Render(content)
 }
 
 // renderStorageTypeView renders the storage type selection menu.
 func (m Model) renderStorageTypeView(header string) string {
-	options := []string{"SQLite", "Binary", "CSV"}
 	optionsText := ""
-	for i, option := range options {
+	for i, storageType := range storageTypeOptions {
 		if i == m.storageTypeSelected {
-			optionsText += selectedStyle.Render("> " + option)
+			optionsText += selectedStyle.Render("> " + storageTypeLabels[storageType])
 		} else {
-			optionsText += optionStyle.Render("  " + option)
+			optionsText += optionStyle.Render("  " + storageTypeLabels[storageType])
 		}
 		optionsText += "\n"
 	}
 
-	content := fmt.Sprintf("%s\n\n%s\n%s\n\n%s\n%s\n%s",
+	content := fmt.Sprintf("%s\n\n%s%s\n%s\n\n%s\n%s\n%s",
 		header,
+		m.renderSelectionError(),
 		titleStyle.Render("Select storage type:"),
-		optionStyle.Render("(SQLite is recommended if you're unsure)"),
+		optionStyle.Render("(Auto picks the fastest format in the export if you're unsure)"),
 		optionsText,
 		helpStyle.Render("Use arrow keys to select and enter to confirm"),
 		helpStyle.Render("Press 'r' to start over or ctrl+c to quit"))
@@ -142,21 +156,31 @@ func (m Model) renderIDInputView(header string) string {
 		"• Description: %s\n"+
 		"• Salt: %s\n",
 		m.config.HashType,
-		m.storageType,
+		m.renderStorageType(),
 		m.hashCount,
 		m.config.EngineVersion,
 		m.config.ExportVersion,
 		m.config.Description,
 		m.config.Salt)
+	if m.indexStats != nil {
+		exportInfo += fmt.Sprintf("• Index: %s\n", m.indexStats)
+	}
+	if m.bloomFilter != nil {
+		exportInfo += fmt.Sprintf("• Bloom Filter: %.2f%% false positives\n", m.bloomFilter.FalsePositiveRate()*100)
+	}
+	for _, warning := range m.warnings {
+		exportInfo += fmt.Sprintf("• Warning: %v\n", warning)
+	}
 
 	var statusText string
 	var helpText string
 	if m.checking {
 		statusText = "\n" + successStyle.Render("Checking ID...")
-		helpText = helpStyle.Render("Please wait...")
+		helpText = helpStyle.Render("Please wait, or press esc to stop")
 	} else {
-		helpText = fmt.Sprintf("%s\n%s",
+		helpText = fmt.Sprintf("%s\n%s\n%s",
 			helpStyle.Render("Press enter when done"),
+			helpStyle.Render("Press 's' to view export stats"),
 			helpStyle.Render("Press 'r' to start over or ctrl+c to quit"))
 	}
 
@@ -170,6 +194,14 @@ func (m Model) renderIDInputView(header string) string {
 	return boxStyle.Render(content)
 }
 
+// renderStorageType renders the storage type of the open export and, if it was detected, why it was chosen.
+func (m Model) renderStorageType() string {
+	if m.detection != nil {
+		return fmt.Sprintf("%s (auto: %s)", m.detection.StorageType, m.detection.Reason)
+	}
+	return string(m.storageType)
+}
+
 // renderResultView renders the check results with status and reason.
 func (m Model) renderResultView(header string) string {
 	var resultText string
@@ -209,7 +241,7 @@ func (m Model) renderFriendsResultView(header string) string {
 		return boxStyle.Render(fmt.Sprintf("%s\n\n%s\n\n%s",
 			header,
 			titleStyle.Render("Checking Friends..."),
-			helpStyle.Render("Please wait...")))
+			helpStyle.Render("Please wait, or press esc to stop and show the friends checked so far")))
 	}
 
 	content := fmt.Sprintf("%s\n\n%s\n\n",
@@ -245,6 +277,11 @@ func (m Model) renderFriendsResultView(header string) string {
 			m.totalFriendCount)
 	}
 
+	if m.checkedFriendCount < m.totalFriendCount {
+		content += "\n" + failureStyle.Render(fmt.Sprintf("Check stopped after %d of %d friends",
+			m.checkedFriendCount, m.totalFriendCount))
+	}
+
 	content += fmt.Sprintf("\n\n%s\n%s\n%s",
 		helpStyle.Render("Use up/down arrows to scroll"),
 		helpStyle.Render("Press enter to check another ID"),
@@ -253,6 +290,64 @@ func (m Model) renderFriendsResultView(header string) string {
 	return boxStyle.Render(content)
 }
 
+// renderStatsView renders the status breakdown, confidence histogram and top reasons of the export.
+func (m Model) renderStatsView(header string) string {
+	if m.checking {
+		return boxStyle.Render(fmt.Sprintf("%s\n\n%s\n\n%s",
+			header,
+			titleStyle.Render("Collecting Export Stats..."),
+			helpStyle.Render("Please wait...")))
+	}
+
+	content := fmt.Sprintf("%s\n\n%s\n",
+		header,
+		titleStyle.Render("Export Stats:"))
+
+	if len(m.stats) == 0 {
+		content += optionStyle.Render("No records found") + "\n"
+	}
+
+	for _, stats := range m.stats {
+		checkTypeStr := string(stats.CheckType)
+		checkTypeStr = strings.ToUpper(checkTypeStr[:1]) + checkTypeStr[1:]
+
+		content += fmt.Sprintf("\n%s %s hashes\n", inputStyle.Render(strconv.Itoa(stats.Total)), checkTypeStr)
+		if stats.Skipped > 0 {
+			content += failureStyle.Render(fmt.Sprintf("%d corrupt records skipped", stats.Skipped)) + "\n"
+		}
+
+		// Status breakdown
+		for _, status := range stats.Statuses() {
+			content += fmt.Sprintf("• %s: %d\n", successStyle.Render(status.Value), status.Count)
+		}
+
+		// Confidence histogram
+		peak := 0
+		for _, count := range stats.Confidence {
+			peak = max(peak, count)
+		}
+		for bucket, count := range stats.Confidence {
+			bar := ""
+			if peak > 0 {
+				bar = strings.Repeat("█", count*20/peak)
+			}
+			content += fmt.Sprintf("%s %s %d\n",
+				optionStyle.Render(checker.BucketLabel(bucket)), confidenceStyle.Render(bar), count)
+		}
+
+		// Most frequent reasons
+		for _, reason := range stats.TopReasons(5) {
+			content += optionStyle.Render(fmt.Sprintf("%5d  %s", reason.Count, reason.Value)) + "\n"
+		}
+	}
+
+	content += fmt.Sprintf("\n%s\n%s",
+		helpStyle.Render("Press enter to go back"),
+		helpStyle.Render("Press 'r' to start over or ctrl+c to quit"))
+
+	return boxStyle.Render(content)
+}
+
 // renderExportDownloadView renders the export download interface.
 func (m Model) renderExportDownloadView(header string) string {
 	if m.downloadError != nil {